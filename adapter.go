@@ -19,3 +19,40 @@ type Adapter interface {
 	// GetAll retrieves all migrations for this adapter
 	GetAll() ([]*Migration, error)
 }
+
+// SchemaDumper may be implemented by adapters able to introspect the database to produce
+// the commands needed to recreate its current schema. It is used to write schema snapshots.
+type SchemaDumper interface {
+	// DumpSchema retrieves commands recreating the current database schema, without data.
+	DumpSchema() (string, error)
+}
+
+// HistoryUpgrader may be implemented by adapters whose history table evolved over time, to
+// bring tables created by older versions up to date.
+type HistoryUpgrader interface {
+	// UpgradeHistory updates the history table to the shape expected by the adapter.
+	UpgradeHistory() error
+}
+
+// Locker may be implemented by adapters able to prevent concurrent runs from different
+// processes, such as many instances of a service starting at the same time.
+type Locker interface {
+	// TryLock acquires the lock without waiting and returns false if it is already held.
+	TryLock() (bool, error)
+	// Unlock releases a lock acquired with TryLock.
+	Unlock() error
+}
+
+// Rename describes a migration renamed from a name to another.
+type Rename struct {
+	From string
+	To   string
+}
+
+// HistoryRenamer may be implemented by adapters able to rename migrations in their history,
+// needed to rename migrations which have already been applied.
+type HistoryRenamer interface {
+	// RenameMigrations renames given migrations in the history, in the given order and all
+	// at once if possible.
+	RenameMigrations(renames []Rename) error
+}
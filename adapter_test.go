@@ -1,6 +1,7 @@
 package migrataur
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -11,6 +12,8 @@ const mockInitialDown = "-- down from mock adapter"
 // mockAdapter implements an in memory migrataur adapter used for testing
 type mockAdapter struct {
 	appliedMigrations []*Migration
+	executed          []string // Commands given to Exec
+	schema            string   // Returned by DumpSchema
 }
 
 func newMockAdapter() *mockAdapter {
@@ -39,6 +42,8 @@ func (a *mockAdapter) MigrationRollbacked(migration *Migration) error {
 }
 
 func (a *mockAdapter) Exec(command string) error {
+	a.executed = append(a.executed, command)
+
 	return nil
 }
 
@@ -46,6 +51,43 @@ func (a *mockAdapter) GetAll() ([]*Migration, error) {
 	return a.appliedMigrations, nil
 }
 
+func (a *mockAdapter) RenameMigrations(renames []Rename) error {
+	for _, r := range renames {
+		for _, m := range a.appliedMigrations {
+			if m.Name == r.From {
+				m.Name = r.To
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *mockAdapter) DumpSchema() (string, error) {
+	return a.schema, nil
+}
+
+// freshAdapter can not record migrations until its history has been created by the initial
+// migration, like a fresh sql database
+type freshAdapter struct {
+	*mockAdapter
+	created bool
+}
+
+func (a *freshAdapter) Exec(command string) error {
+	a.created = a.created || command == "create history"
+
+	return a.mockAdapter.Exec(command)
+}
+
+func (a *freshAdapter) MigrationApplied(migration *Migration) error {
+	if !a.created {
+		return fmt.Errorf("the history table does not exist")
+	}
+
+	return a.mockAdapter.MigrationApplied(migration)
+}
+
 func TestMockAdapter(t *testing.T) {
 	adapter := newMockAdapter()
 	assert := assert(t)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/YuukanOO/migrataur"
 )
@@ -22,11 +23,22 @@ const DefaultPlaceholder = "?"
 // PostgrePlaceholder holds the default placeholder for pg databases.
 const PostgrePlaceholder = "${i}"
 
+// Headers of the initial migration sections, used to recognize them since their statements
+// must be executed one by one (some drivers, such as MySQL ones, reject multiple statements)
+const (
+	initialUpHeader   = "-- Do not edit this migration unless you know what you're doing!"
+	initialDownHeader = "-- Warning also apply to this section ;)"
+)
+
 // Adapter implements the interface defined by migrataur for common SQL databases
 type Adapter struct {
 	tableName   string
 	placeholder string
 	db          *sql.DB
+	// autoUpgrade upgrades the history table when migrations are retrieved
+	autoUpgrade bool
+	// historyVersion caches the version of the history table, 0 when not known yet
+	historyVersion int
 }
 
 // WithDB constructs a sql adapter with the given DB handle.
@@ -52,25 +64,75 @@ func WithDBAndOptions(db *sql.DB, table, placeholder string) *Adapter {
 	return adapter
 }
 
+// ForTable constructs a new adapter sharing the same DB handle and placeholder but
+// storing its history in the given table. Use it to give each migrataur set its own history.
+func (a *Adapter) ForTable(table string) *Adapter {
+	adapter := WithDBAndOptions(a.db, table, a.placeholder)
+	adapter.autoUpgrade = a.autoUpgrade
+
+	return adapter
+}
+
+// WithAutoUpgrade makes the adapter upgrade its history table, if it has been created by an
+// older version, as soon as migrations are retrieved. Without it, call UpgradeHistory.
+func (a *Adapter) WithAutoUpgrade() *Adapter {
+	a.autoUpgrade = true
+
+	return a
+}
+
 func (a *Adapter) getPlaceholder(idx int) string {
 	return strings.Replace(a.placeholder, "{i}", strconv.Itoa(idx), -1)
 }
 
 // GetInitialMigration retrieves the migration up and down code and is used to populate
-// the migrations history table.
+// the migrations history table. The history is created with its latest shape.
 func (a *Adapter) GetInitialMigration() (up, down string) {
-	return fmt.Sprintf(`-- Do not edit this migration unless you know what you're doing!
+	columns := ""
+
+	for _, column := range append(metadataColumns, fakeColumn) {
+		columns += fmt.Sprintf(",\n\t%s %s", column.name, column.definition)
+	}
+
+	return fmt.Sprintf(`%s
 create table %s(
 	name varchar(250) primary key,
-	applied_at timestamp not null
-);`, a.tableName), fmt.Sprintf(`-- Warning also apply to this section ;)
-drop table %s;`, a.tableName)
+	applied_at timestamp not null%s
+);
+%s
+create table %s(version int not null);
+insert into %s values (%d);`, initialUpHeader, a.tableName, columns, a.appliedAtIndex(), a.versionTableName(), a.versionTableName(), latestHistoryVersion()),
+		fmt.Sprintf(`%s
+drop table %s;
+drop table %s;`, initialDownHeader, a.versionTableName(), a.tableName)
 }
 
 // MigrationApplied is called when the migration has been successfully applied by the
 // adapter. This is where you should insert the migration in the history.
 func (a *Adapter) MigrationApplied(migration *migrataur.Migration) error {
-	_, err := a.db.Exec(fmt.Sprintf("insert into %s values (%s, %s)", a.tableName, a.getPlaceholder(1), a.getPlaceholder(2)), migration.Name, *migration.AppliedAt)
+	version := a.HistoryVersion()
+	columns := []string{"name", "applied_at"}
+	values := []interface{}{migration.Name, *migration.AppliedAt}
+
+	if version >= metadataHistoryVersion {
+		columns = append(columns, "duration_ms", "applied_by", "migrataur_version", "run_id")
+		values = append(values, int64(migration.Duration/time.Millisecond),
+			nullString(migration.AppliedBy), nullString(migration.Version), nullString(migration.RunID))
+	}
+
+	if version >= fakeHistoryVersion {
+		columns = append(columns, fakeColumn.name)
+		values = append(values, migration.Fake)
+	}
+
+	placeholders := make([]string, len(values))
+
+	for i := range values {
+		placeholders[i] = a.getPlaceholder(i + 1)
+	}
+
+	_, err := a.db.Exec(fmt.Sprintf("insert into %s (%s) values (%s)",
+		a.tableName, strings.Join(columns, ", "), strings.Join(placeholders, ", ")), values...)
 
 	return err
 }
@@ -88,18 +150,75 @@ func (a *Adapter) MigrationRollbacked(migration *migrataur.Migration) error {
 }
 
 // Exec the given commands. This is call by Migrataur to apply or rollback a migration
-// with the corresponding code.
+// with the corresponding code. Statements of the initial migration are executed one by one.
 func (a *Adapter) Exec(command string) error {
-	_, err := a.db.Exec(command)
+	trimmed := strings.TrimSpace(command)
 
-	return err
+	if !strings.HasPrefix(trimmed, initialUpHeader) && !strings.HasPrefix(trimmed, initialDownHeader) {
+		_, err := a.db.Exec(command)
+
+		return err
+	}
+
+	// The history table is created or dropped, its version must be detected again
+	a.historyVersion = 0
+
+	for _, statement := range splitStatements(trimmed) {
+		if _, err := a.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitStatements splits the given commands on lines ending with a semicolon. It is only
+// meant for commands generated by this adapter, which never span such lines.
+func splitStatements(commands string) []string {
+	statements := []string{}
+	current := []string{}
+
+	for _, line := range strings.Split(commands, "\n") {
+		current = append(current, line)
+		trimmed := strings.TrimSpace(line)
+
+		if !strings.HasPrefix(trimmed, "--") && strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	if rest := strings.TrimSpace(strings.Join(current, "\n")); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
 }
 
 // GetAll retrieves all migrations for this adapter
 func (a *Adapter) GetAll() ([]*migrataur.Migration, error) {
+	if a.autoUpgrade {
+		if version := a.HistoryVersion(); version > 0 && version < latestHistoryVersion() {
+			if err := a.UpgradeHistory(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	version := a.HistoryVersion()
+	columns := "name, applied_at"
+
+	if version >= metadataHistoryVersion {
+		columns += ", duration_ms, applied_by, migrataur_version, run_id"
+	}
+
+	if version >= fakeHistoryVersion {
+		columns += ", " + fakeColumn.name
+	}
+
 	// If the database has not been initialized, the migration table doesn't exist yet
 	// so fail silently for now
-	rows, err := a.db.Query(fmt.Sprintf("select name, applied_at from %s order by name", a.tableName))
+	rows, err := a.db.Query(fmt.Sprintf("select %s from %s order by name", columns, a.tableName))
 
 	migrations := []*migrataur.Migration{}
 
@@ -110,14 +229,156 @@ func (a *Adapter) GetAll() ([]*migrataur.Migration, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var migration = &migrataur.Migration{}
+		var (
+			migration                          = &migrataur.Migration{}
+			duration                           sql.NullInt64
+			appliedBy, migrataurVersion, runID sql.NullString
+			fake                               sql.NullBool
+		)
+
+		dest := []interface{}{&migration.Name, &migration.AppliedAt}
+
+		if version >= metadataHistoryVersion {
+			dest = append(dest, &duration, &appliedBy, &migrataurVersion, &runID)
+		}
 
-		if err = rows.Scan(&migration.Name, &migration.AppliedAt); err != nil {
+		if version >= fakeHistoryVersion {
+			dest = append(dest, &fake)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 
+		migration.Duration = time.Duration(duration.Int64) * time.Millisecond
+		migration.AppliedBy = appliedBy.String
+		migration.Version = migrataurVersion.String
+		migration.RunID = runID.String
+		migration.Fake = fake.Bool
+
 		migrations = append(migrations, migration)
 	}
 
 	return migrations, nil
 }
+
+// nullString stores empty strings as null
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// DumpSchema builds create table statements for every table of the current schema by reading
+// the standard information_schema. Only columns, their types, nullability and primary keys are
+// retrieved, other constraints and indexes are not. Tables used by the adapter itself
+// (history, version and lock) are left out.
+func (a *Adapter) DumpSchema() (string, error) {
+	rows, err := a.db.Query(fmt.Sprintf(`select table_name, column_name, data_type, character_maximum_length, is_nullable
+from information_schema.columns
+where table_schema = %s
+	and table_name not in (%s, %s, %s)
+order by table_name, ordinal_position`, a.currentSchema(), a.getPlaceholder(1), a.getPlaceholder(2), a.getPlaceholder(3)),
+		a.tableName, a.versionTableName(), a.lockTableName())
+
+	if err != nil {
+		return "", err
+	}
+
+	defer rows.Close()
+
+	tables := []string{}
+	columns := map[string][]string{}
+
+	for rows.Next() {
+		var (
+			table, column, dataType, nullable string
+			maxLength                         sql.NullInt64
+		)
+
+		if err = rows.Scan(&table, &column, &dataType, &maxLength, &nullable); err != nil {
+			return "", err
+		}
+
+		if _, ok := columns[table]; !ok {
+			tables = append(tables, table)
+		}
+
+		definition := fmt.Sprintf("%s %s", column, dataType)
+
+		if maxLength.Valid {
+			definition += fmt.Sprintf("(%d)", maxLength.Int64)
+		}
+
+		if nullable == "NO" {
+			definition += " not null"
+		}
+
+		columns[table] = append(columns[table], definition)
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	primaryKeys, err := a.getPrimaryKeys()
+
+	if err != nil {
+		return "", err
+	}
+
+	statements := make([]string, len(tables))
+
+	for i, table := range tables {
+		definitions := columns[table]
+
+		if keys, ok := primaryKeys[table]; ok {
+			definitions = append(definitions, fmt.Sprintf("primary key (%s)", strings.Join(keys, ", ")))
+		}
+
+		statements[i] = fmt.Sprintf("create table %s(\n\t%s\n);", table, strings.Join(definitions, ",\n\t"))
+	}
+
+	return strings.Join(statements, "\n\n"), nil
+}
+
+// currentSchema retrieves the sql expression returning the schema the adapter is connected
+// to. Postgres is recognized by its placeholder, others are expected to behave like MySQL.
+func (a *Adapter) currentSchema() string {
+	if a.placeholder == PostgrePlaceholder {
+		return "current_schema()"
+	}
+
+	return "database()"
+}
+
+// getPrimaryKeys retrieves primary keys columns of the current schema by table name
+func (a *Adapter) getPrimaryKeys() (map[string][]string, error) {
+	rows, err := a.db.Query(fmt.Sprintf(`select tc.table_name, kcu.column_name
+from information_schema.table_constraints tc
+join information_schema.key_column_usage kcu
+	on kcu.constraint_name = tc.constraint_name
+	and kcu.table_schema = tc.table_schema
+	and kcu.table_name = tc.table_name
+where tc.constraint_type = 'PRIMARY KEY'
+	and tc.table_schema = %s
+order by tc.table_name, kcu.ordinal_position`, a.currentSchema()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := map[string][]string{}
+
+	for rows.Next() {
+		var table, column string
+
+		if err = rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+
+		keys[table] = append(keys[table], column)
+	}
+
+	return keys, rows.Err()
+}
@@ -0,0 +1,175 @@
+package sql
+
+import "fmt"
+
+// metadataColumns holds columns added to the history table to record how migrations have been
+// applied.
+var metadataColumns = []struct{ name, definition string }{
+	{"duration_ms", "bigint"},
+	{"applied_by", "varchar(250)"},
+	{"migrataur_version", "varchar(50)"},
+	{"run_id", "varchar(250)"},
+}
+
+// fakeColumn records migrations applied with the Fake run option, their commands were not
+// executed
+var fakeColumn = struct{ name, definition string }{"fake", "boolean"}
+
+// historyUpgrade is an internal migration of the history table itself
+type historyUpgrade struct {
+	description string
+	commands    func(a *Adapter) []string
+}
+
+// historyUpgrades lists every change made to the history table, in order. A history at
+// version n has the n first upgrades applied. Never edit or reorder existing ones, append
+// a new upgrade instead and update GetInitialMigration so new installs get the same shape.
+var historyUpgrades = []historyUpgrade{
+	{
+		description: "create the history table",
+		commands: func(a *Adapter) []string {
+			// Always done by the initial migration
+			return nil
+		},
+	},
+	{
+		description: "record how migrations have been applied",
+		commands: func(a *Adapter) []string {
+			commands := []string{}
+
+			for _, column := range metadataColumns {
+				if !a.hasColumn(column.name) {
+					commands = append(commands, fmt.Sprintf("alter table %s add column %s %s", a.tableName, column.name, column.definition))
+				}
+			}
+
+			return commands
+		},
+	},
+	{
+		description: "index application dates",
+		commands: func(a *Adapter) []string {
+			return []string{a.appliedAtIndex()}
+		},
+	},
+	{
+		description: "record fake applications",
+		commands: func(a *Adapter) []string {
+			if a.hasColumn(fakeColumn.name) {
+				return nil
+			}
+
+			return []string{fmt.Sprintf("alter table %s add column %s %s", a.tableName, fakeColumn.name, fakeColumn.definition)}
+		},
+	},
+}
+
+// metadataHistoryVersion is the first version recording how migrations have been applied
+const metadataHistoryVersion = 2
+
+// fakeHistoryVersion is the first version recording fake applications
+const fakeHistoryVersion = 4
+
+// latestHistoryVersion retrieves the version of the history table expected by this adapter
+func latestHistoryVersion() int {
+	return len(historyUpgrades)
+}
+
+// HistoryVersion retrieves the version of the history table, 0 if it does not exist yet.
+// Tables created before versions were recorded are detected from their columns. Once
+// detected, the version is cached until the history is upgraded or the initial migration
+// executed again.
+func (a *Adapter) HistoryVersion() int {
+	if a.historyVersion == 0 {
+		a.historyVersion = a.detectHistoryVersion()
+	}
+
+	return a.historyVersion
+}
+
+// detectHistoryVersion reads the version of the history table from the database
+func (a *Adapter) detectHistoryVersion() int {
+	if !a.hasColumn("name") {
+		return 0
+	}
+
+	var version int
+
+	if err := a.db.QueryRow(fmt.Sprintf("select version from %s", a.versionTableName())).Scan(&version); err == nil {
+		return version
+	}
+
+	for _, column := range metadataColumns {
+		if !a.hasColumn(column.name) {
+			return 1
+		}
+	}
+
+	return metadataHistoryVersion
+}
+
+// UpgradeHistory applies internal upgrades needed to bring a history table created by an
+// older version of this adapter to its latest shape, recording the reached version.
+func (a *Adapter) UpgradeHistory() error {
+	version := a.HistoryVersion()
+
+	if version == 0 {
+		return fmt.Errorf("the history table %s does not exist, apply the initial migration first", a.tableName)
+	}
+
+	for ; version < latestHistoryVersion(); version++ {
+		upgrade := historyUpgrades[version]
+
+		for _, command := range upgrade.commands(a) {
+			if _, err := a.db.Exec(command); err != nil {
+				return fmt.Errorf("could not %s: %s", upgrade.description, err)
+			}
+		}
+
+		if err := a.setHistoryVersion(version + 1); err != nil {
+			return err
+		}
+
+		a.historyVersion = version + 1
+	}
+
+	return nil
+}
+
+// setHistoryVersion records the version of the history table
+func (a *Adapter) setHistoryVersion(version int) error {
+	table := a.versionTableName()
+
+	if _, err := a.db.Exec(fmt.Sprintf("create table if not exists %s(version int not null)", table)); err != nil {
+		return err
+	}
+
+	if _, err := a.db.Exec(fmt.Sprintf("delete from %s", table)); err != nil {
+		return err
+	}
+
+	_, err := a.db.Exec(fmt.Sprintf("insert into %s values (%s)", table, a.getPlaceholder(1)), version)
+
+	return err
+}
+
+// versionTableName retrieves the name of the table holding the history version
+func (a *Adapter) versionTableName() string {
+	return a.tableName + "_version"
+}
+
+// appliedAtIndex retrieves the command creating the index on application dates
+func (a *Adapter) appliedAtIndex() string {
+	return fmt.Sprintf("create index %s_applied_at on %s(applied_at);", a.tableName, a.tableName)
+}
+
+// hasColumn checks if the history table has the given column by selecting it
+func (a *Adapter) hasColumn(column string) bool {
+	rows, err := a.db.Query(fmt.Sprintf("select %s from %s where 1 = 0", column, a.tableName))
+
+	if err != nil {
+		return false
+	}
+
+	return rows.Close() == nil
+}
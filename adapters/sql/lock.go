@@ -0,0 +1,40 @@
+package sql
+
+import "fmt"
+
+// TryLock acquires the migrations lock by inserting a row in a dedicated table, created if
+// needed. If a process holding the lock crashed, the row must be removed by hand.
+func (a *Adapter) TryLock() (bool, error) {
+	table := a.lockTableName()
+
+	if _, err := a.db.Exec(fmt.Sprintf("create table if not exists %s(id int primary key, locked_at timestamp not null)", table)); err != nil {
+		return false, err
+	}
+
+	_, err := a.db.Exec(fmt.Sprintf("insert into %s values (1, current_timestamp)", table))
+
+	if err == nil {
+		return true, nil
+	}
+
+	// The insert may have failed because the lock is already held, or for another reason
+	var held int
+
+	if countErr := a.db.QueryRow(fmt.Sprintf("select count(*) from %s", table)).Scan(&held); countErr == nil && held > 0 {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Unlock releases the migrations lock.
+func (a *Adapter) Unlock() error {
+	_, err := a.db.Exec(fmt.Sprintf("delete from %s", a.lockTableName()))
+
+	return err
+}
+
+// lockTableName retrieves the name of the table used as a lock
+func (a *Adapter) lockTableName() string {
+	return a.tableName + "_lock"
+}
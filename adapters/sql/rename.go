@@ -0,0 +1,27 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/YuukanOO/migrataur"
+)
+
+// RenameMigrations renames given migrations in the history within a single transaction.
+func (a *Adapter) RenameMigrations(renames []migrataur.Rename) error {
+	tx, err := a.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("update %s set name = %s where name = %s", a.tableName, a.getPlaceholder(1), a.getPlaceholder(2))
+
+	for _, r := range renames {
+		if _, err = tx.Exec(query, r.To, r.From); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,37 +1,431 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/YuukanOO/migrataur"
 	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
 )
 
+// setFlag is accepted by every command to target a specific migrations set
+var setFlag = cli.StringFlag{
+	Name:  "set",
+	Usage: "Name of the migrations set to use, the default one if not given",
+}
+
+// fakeFlag is accepted by commands which can only update the history
+var fakeFlag = cli.BoolFlag{
+	Name:  "fake",
+	Usage: "Only updates the history, migrations commands will NOT be executed",
+}
+
+// allowIrreversibleFlag is accepted by commands which rollback migrations
+var allowIrreversibleFlag = cli.BoolFlag{
+	Name:  "allow-irreversible",
+	Usage: "Rollbacks migrations even if they are marked as irreversible",
+}
+
+// runIDFlag is accepted by commands applying migrations to record a run identifier
+var runIDFlag = cli.StringFlag{
+	Name:  "run-id",
+	Usage: "Identifier recorded in the history alongside applied migrations, a deployment ID for example",
+}
+
+// allowProtectedFlag is accepted by commands refused in protected environments
+var allowProtectedFlag = cli.BoolFlag{
+	Name:  "allow-protected",
+	Usage: "Runs the command even if the environment is protected",
+}
+
+// yesFlag is accepted by commands asking for a confirmation
+var yesFlag = cli.BoolFlag{
+	Name:  "yes, y",
+	Usage: "Does not ask for a confirmation",
+}
+
+// globalFlags reconfigure the instance before each command
+var globalFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "dir",
+		Usage: "Directory containing migrations of the targeted set",
+	},
+	cli.BoolFlag{
+		Name:  "quiet, q",
+		Usage: "Only outputs results, warnings and failures",
+	},
+	cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "Also outputs executed commands",
+	},
+	cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Disables colors, also disabled by the NO_COLOR environment variable",
+	},
+	cli.StringFlag{
+		Name:  "format",
+		Value: "text",
+		Usage: "Output format, text or json",
+	},
+}
+
+// configure overrides instance options with global flags
+func configure(c *cli.Context, instance *migrataur.Migrataur) error {
+	if c.GlobalIsSet("dir") {
+		// Each set has its own directory so a single one can not be given for all of them
+		if c.Bool("all-sets") {
+			return fmt.Errorf("--dir can not be used with --all-sets")
+		}
+
+		if err := instance.OverrideDirectory(c.GlobalString("dir")); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case c.GlobalBool("quiet") && c.GlobalBool("verbose"):
+		return fmt.Errorf("--quiet and --verbose can not be used together")
+	case c.GlobalBool("quiet"):
+		instance.OverrideMinLevel(migrataur.LevelWarn)
+	case c.GlobalBool("verbose"):
+		instance.OverrideMinLevel(migrataur.LevelDebug)
+	}
+
+	opts := instance.Options()
+
+	switch format := c.GlobalString("format"); format {
+	case "json":
+		instance.OverrideEventHandler(migrataur.JSONHandler(os.Stdout))
+	case "text", "":
+		// Custom handlers are left untouched
+		if opts.EventHandler == nil && opts.Logger != nil && useColors(c) {
+			instance.OverrideEventHandler(migrataur.ColoredPrintfHandler(opts.Logger))
+		}
+	default:
+		return fmt.Errorf("unknown format %s, should be text or json", format)
+	}
+
+	return nil
+}
+
+// useColors checks if colors should be used, only when writing to a terminal
+func useColors(c *cli.Context) bool {
+	if c.GlobalBool("no-color") || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runOptions builds migrataur run options from command flags
+func runOptions(c *cli.Context) []migrataur.RunOption {
+	opts := []migrataur.RunOption{}
+
+	if c.Bool("fake") {
+		opts = append(opts, migrataur.Fake())
+	}
+
+	if c.Bool("allow-irreversible") {
+		opts = append(opts, migrataur.AllowIrreversible())
+	}
+
+	if c.Bool("allow-protected") {
+		opts = append(opts, migrataur.AllowProtected())
+	}
+
+	if id := c.String("run-id"); id != "" {
+		opts = append(opts, migrataur.WithRunID(id))
+	}
+
+	return opts
+}
+
+// readSource reads the given file, or stdin if it is "-"
+func readSource(source string) ([]byte, error) {
+	if source == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+
+	return ioutil.ReadFile(source)
+}
+
+// confirm asks the user to confirm on stdin, unless the --yes flag has been given
+func confirm(c *cli.Context, question string) bool {
+	if c.Bool("yes") {
+		return true
+	}
+
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// printPlan lists migrations a destructive command is about to touch
+func printPlan(instance *migrataur.Migrataur, plan []*migrataur.Migration) {
+	for _, m := range plan {
+		instance.Printf("\t%s", m)
+	}
+}
+
+// openEditor opens the given file in the editor defined by $VISUAL or $EDITOR
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		return fmt.Errorf("no editor configured, set $VISUAL or $EDITOR")
+	}
+
+	// The editor may be given with its own arguments, such as "code --wait"
+	args := strings.Fields(editor)
+	command := exec.Command(args[0], append(args[1:], path)...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	return command.Run()
+}
+
+// Factory builds the migrataur instance used by commands. It receives the context of the
+// command being run so it can be configured with global flags.
+type Factory func(c *cli.Context) (*migrataur.Migrataur, error)
+
 // For constructs a CLI for the given migrataur instance.
-func For(instance *migrataur.Migrataur) *cli.App {
+func For(root *migrataur.Migrataur) *cli.App {
+	return ForFactory(func(*cli.Context) (*migrataur.Migrataur, error) {
+		return root, nil
+	})
+}
+
+// ForFactory constructs a CLI whose migrataur instance is built by the given factory the
+// first time a command needs it, so that showing the help does not require a database.
+func ForFactory(factory Factory) *cli.App {
+	var root *migrataur.Migrataur
+
+	// getSet retrieves the set targeted by the --set flag
+	getSet := func(c *cli.Context) (*migrataur.Migrataur, error) {
+		if root == nil {
+			instance, err := factory(c)
+
+			if err != nil {
+				return nil, err
+			}
+
+			root = instance
+		}
+
+		instance, err := root.Set(c.String("set"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return instance, configure(c, instance)
+	}
+
 	app := cli.NewApp()
+	app.Flags = append([]cli.Flag{}, globalFlags...)
 	app.Commands = []cli.Command{
 		{
 			Name:  "list",
 			Usage: "List all migrations",
+			Flags: []cli.Flag{
+				setFlag,
+				cli.BoolFlag{
+					Name:  "details",
+					Usage: "Shows when, by whom and how fast migrations have been applied",
+				},
+			},
 			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				migrations, err := instance.GetAll()
+
+				if err != nil {
+					return err
+				}
+
+				for _, m := range migrations {
+					if c.Bool("details") {
+						instance.Printf("%s\t%s", m, m.Details())
+					} else {
+						instance.Printf(m.String())
+					}
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "Shows the last applied migration and how many are pending",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
 				migrations, err := instance.GetAll()
 
 				if err != nil {
 					return err
 				}
 
+				var last *migrataur.Migration
+				pending := 0
+
 				for _, m := range migrations {
-					instance.Printf(m.String())
+					if m.IsOrphan() {
+						continue
+					} else if !m.HasBeenApplied() {
+						pending++
+					} else if last == nil || m.AppliedAt.After(*last.AppliedAt) {
+						last = m
+					}
+				}
+
+				if last == nil {
+					instance.Printf("No migration applied yet")
+				} else {
+					instance.Printf("Last applied: %s\t%s", last.Name, last.Details())
 				}
 
+				instance.Printf("Pending: %d", pending)
+
 				return nil
 			},
 		},
+		{
+			Name:  "upgrade-history",
+			Usage: "Upgrades a history table created by an older version of the adapter",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				return instance.UpgradeHistory()
+			},
+		},
+		{
+			Name:  "prune-history",
+			Usage: "Removes from the history migrations whose file was not found",
+			Flags: []cli.Flag{setFlag, yesFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				orphans, err := instance.GetOrphans()
+
+				if err != nil {
+					return err
+				}
+
+				if len(orphans) == 0 {
+					instance.Printf("No orphan in the history")
+					return nil
+				}
+
+				for _, m := range orphans {
+					instance.Printf("%s\t%s", m.Name, m.Details())
+				}
+
+				if !confirm(c, fmt.Sprintf("Remove %d migrations from the history?", len(orphans))) {
+					return fmt.Errorf("aborted")
+				}
+
+				_, err = instance.PruneHistory()
+
+				return err
+			},
+		},
 		{
 			Name:  "init",
 			Usage: "Generates the initial migration provided by the adapter",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				_, err = instance.Init()
+
+				if err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "baseline",
+			Usage: "Marks migrations up to the given one as applied without executing them. If you do not provide one, all migrations will be marked.",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				_, err = instance.Baseline(c.Args().First())
+
+				if err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "dump-schema",
+			Usage: "Writes a snapshot of the database schema to the configured schema file",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				return instance.DumpSchema()
+			},
+		},
+		{
+			Name:  "load-schema",
+			Usage: "Bootstraps a fresh database from the schema file and marks included migrations as applied",
+			Flags: []cli.Flag{setFlag},
 			Action: func(c *cli.Context) error {
-				_, err := instance.Init()
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				_, err = instance.LoadSchema()
 
 				if err != nil {
 					return err
@@ -43,33 +437,125 @@ func For(instance *migrataur.Migrataur) *cli.App {
 		{
 			Name:  "new",
 			Usage: "Creates a new migration with the given name",
+			Flags: []cli.Flag{
+				setFlag,
+				cli.StringFlag{
+					Name:  "from-file",
+					Usage: "Populates the migration with the content of the given file, - to read it from stdin",
+				},
+				cli.StringFlag{
+					Name:  "template",
+					Usage: "Populates the migration with the given skeleton of the templates directory",
+				},
+				cli.BoolFlag{
+					Name:  "edit",
+					Usage: "Opens the migration in $VISUAL or $EDITOR once created",
+				},
+			},
 			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
 				name := c.Args().First()
 
 				if name == "" {
 					return fmt.Errorf("you should provide a name")
 				}
 
-				_, err := instance.New(name)
+				opts := []migrataur.NewOption{}
+
+				if tmpl := c.String("template"); tmpl != "" {
+					opts = append(opts, migrataur.FromTemplate(tmpl))
+				}
+
+				if source := c.String("from-file"); source != "" {
+					content, err := readSource(source)
+
+					if err != nil {
+						return err
+					}
+
+					opts = append(opts, migrataur.WithContent(content))
+				}
+
+				migration, err := instance.New(name, opts...)
 
 				if err != nil {
 					return err
 				}
 
+				if c.Bool("edit") {
+					return openEditor(instance.MigrationPath(migration))
+				}
+
 				return nil
 			},
 		},
 		{
 			Name:  "remove",
 			Usage: "Removes one or many migrations",
+			Flags: []cli.Flag{setFlag, allowIrreversibleFlag, yesFlag},
 			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
 				nameOrRange := c.Args().First()
 
 				if nameOrRange == "" {
 					return fmt.Errorf("you should provide a name or range to remove")
 				}
 
-				_, err := instance.Remove(nameOrRange)
+				plan, err := instance.PlanRemove(nameOrRange)
+
+				if err != nil {
+					return err
+				}
+
+				if len(plan) == 0 {
+					instance.Printf("Nothing to remove")
+					return nil
+				}
+
+				instance.Printf("The following migrations will be deleted, applied ones will be rolled back first:")
+				printPlan(instance, plan)
+
+				if !confirm(c, fmt.Sprintf("Remove %d migrations?", len(plan))) {
+					return fmt.Errorf("aborted")
+				}
+
+				_, err = instance.Remove(nameOrRange, runOptions(c)...)
+
+				if err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "squash",
+			Usage: "Merges migrations of the given range into a single one",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				nameOrRange := c.Args().First()
+
+				if nameOrRange == "" {
+					return fmt.Errorf("you should provide a range to squash")
+				}
+
+				_, err = instance.Squash(nameOrRange)
 
 				if err != nil {
 					return err
@@ -78,11 +564,84 @@ func For(instance *migrataur.Migrataur) *cli.App {
 				return nil
 			},
 		},
+		{
+			Name:      "rename",
+			Usage:     "Renames a migration file and its row in the history if it has been applied",
+			ArgsUsage: "<migration> <new name>",
+			Flags:     []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				if c.NArg() != 2 {
+					return fmt.Errorf("you should provide the migration and its new name")
+				}
+
+				_, err = instance.Rename(c.Args().Get(0), c.Args().Get(1))
+
+				return err
+			},
+		},
+		{
+			Name:  "renumber",
+			Usage: "Renames every migration to use zero padded incremental sequences",
+			Flags: []cli.Flag{
+				setFlag,
+				cli.IntFlag{
+					Name:  "width",
+					Value: 4,
+					Usage: "Number of digits of sequences",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				_, err = instance.Renumber(c.Int("width"))
+
+				return err
+			},
+		},
 		{
 			Name:  "reset",
 			Usage: "Reset the database",
+			Flags: []cli.Flag{setFlag, allowIrreversibleFlag, allowProtectedFlag, yesFlag},
 			Action: func(c *cli.Context) error {
-				_, err := instance.Reset()
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				if instance.IsProtected() && !c.Bool("allow-protected") {
+					return migrataur.ErrProtectedEnvironment
+				}
+
+				plan, err := instance.PlanReset()
+
+				if err != nil {
+					return err
+				}
+
+				if len(plan) == 0 {
+					instance.Printf("Nothing to rollback")
+					return nil
+				}
+
+				instance.Printf("The following migrations will be rolled back:")
+				printPlan(instance, plan)
+
+				if !confirm(c, fmt.Sprintf("Reset the database by rolling back %d migrations?", len(plan))) {
+					return fmt.Errorf("aborted")
+				}
+
+				_, err = instance.Reset(runOptions(c)...)
 
 				if err != nil {
 					return err
@@ -94,17 +653,57 @@ func For(instance *migrataur.Migrataur) *cli.App {
 		{
 			Name:  "migrate",
 			Usage: "Migrates given range or migration. If you do not provide a range, it will apply all pending migrations.",
+			Flags: []cli.Flag{
+				setFlag,
+				fakeFlag,
+				runIDFlag,
+				cli.BoolFlag{
+					Name:  "all-sets",
+					Usage: "Migrates every set to its latest version, in dependency order",
+				},
+			},
 			Action: func(c *cli.Context) error {
-				var err error
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
 
 				nameOrRange := c.Args().First()
 
-				if nameOrRange == "" {
-					_, err = instance.MigrateToLatest()
+				if c.Bool("all-sets") {
+					// Every set is migrated to its latest version, there is nothing to target
+					if c.IsSet("set") || nameOrRange != "" {
+						return fmt.Errorf("--all-sets can not be used with --set or a range")
+					}
+
+					_, err = instance.MigrateAllSets(runOptions(c)...)
+				} else if nameOrRange == "" {
+					_, err = instance.MigrateToLatest(runOptions(c)...)
 				} else {
-					_, err = instance.Migrate(nameOrRange)
+					_, err = instance.Migrate(nameOrRange, runOptions(c)...)
+				}
+
+				if err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "verify",
+			Usage: "Applies, rollbacks and applies again each pending migration to check they are reversible. Use it against a throwaway database!",
+			Flags: []cli.Flag{setFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
 				}
 
+				_, err = instance.VerifyRoundtrip()
+
 				if err != nil {
 					return err
 				}
@@ -112,17 +711,49 @@ func For(instance *migrataur.Migrataur) *cli.App {
 				return nil
 			},
 		},
+		{
+			Name:      "redo",
+			Usage:     "Rollbacks the last applied migrations and applies them again, 1 if not given",
+			ArgsUsage: "[n]",
+			Flags:     []cli.Flag{setFlag, allowIrreversibleFlag},
+			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
+				n := 1
+
+				if arg := c.Args().First(); arg != "" {
+					if n, err = strconv.Atoi(arg); err != nil {
+						return fmt.Errorf("%s is not a valid number of migrations", arg)
+					}
+				}
+
+				_, err = instance.Redo(n, runOptions(c)...)
+
+				return err
+			},
+		},
 		{
 			Name:  "rollback",
 			Usage: "Rollbacks given range or migration",
+			Flags: []cli.Flag{setFlag, fakeFlag, allowIrreversibleFlag},
 			Action: func(c *cli.Context) error {
+				instance, err := getSet(c)
+
+				if err != nil {
+					return err
+				}
+
 				nameOrRange := c.Args().First()
 
 				if nameOrRange == "" {
 					return fmt.Errorf("you should provide a name or range to rollback")
 				}
 
-				_, err := instance.Rollback(nameOrRange)
+				_, err = instance.Rollback(nameOrRange, runOptions(c)...)
 
 				if err != nil {
 					return err
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFiles are looked up in the working directory when no config file is given
+var defaultConfigFiles = []string{"migrataur.yml", "migrataur.yaml", "migrataur.toml"}
+
+// config holds settings read from the config file, the environment and global flags
+type config struct {
+	Driver      string  `yaml:"driver" toml:"driver"`
+	DSN         string  `yaml:"dsn" toml:"dsn"`
+	Directory   string  `yaml:"directory" toml:"directory"`
+	Extension   string  `yaml:"extension" toml:"extension"`
+	Table       string  `yaml:"table" toml:"table"`
+	Placeholder string  `yaml:"placeholder" toml:"placeholder"`
+	Markers     markers `yaml:"markers" toml:"markers"`
+}
+
+// markers overrides the up and down delimiters of migration files
+type markers struct {
+	UpStart   string `yaml:"up_start" toml:"up_start"`
+	UpEnd     string `yaml:"up_end" toml:"up_end"`
+	DownStart string `yaml:"down_start" toml:"down_start"`
+	DownEnd   string `yaml:"down_end" toml:"down_end"`
+}
+
+// setting is a single config value which can be overridden by an environment variable
+// and a global flag, in that order of precedence.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	value func(*config) *string
+}
+
+var settings = []setting{
+	{"driver", "MIGRATAUR_DRIVER", "Name of the database/sql driver", func(c *config) *string { return &c.Driver }},
+	{"dsn", "MIGRATAUR_DSN", "Data source name given to the driver", func(c *config) *string { return &c.DSN }},
+	{"dir", "MIGRATAUR_DIR", "Directory containing migrations", func(c *config) *string { return &c.Directory }},
+	{"extension", "MIGRATAUR_EXTENSION", "Extension of migration files", func(c *config) *string { return &c.Extension }},
+	{"table", "MIGRATAUR_TABLE", "Name of the history table", func(c *config) *string { return &c.Table }},
+	{"placeholder", "MIGRATAUR_PLACEHOLDER", "Placeholder used in sql queries, ${i} for postgres", func(c *config) *string { return &c.Placeholder }},
+	{"up-start", "MIGRATAUR_UP_START", "Line starting the up section", func(c *config) *string { return &c.Markers.UpStart }},
+	{"up-end", "MIGRATAUR_UP_END", "Line ending the up section", func(c *config) *string { return &c.Markers.UpEnd }},
+	{"down-start", "MIGRATAUR_DOWN_START", "Line starting the down section", func(c *config) *string { return &c.Markers.DownStart }},
+	{"down-end", "MIGRATAUR_DOWN_END", "Line ending the down section", func(c *config) *string { return &c.Markers.DownEnd }},
+}
+
+// configFlags builds the flags overriding the config file, skipping those already defined
+// by the cmd package such as --dir
+func configFlags(existing []cli.Flag) []cli.Flag {
+	defined := map[string]bool{}
+
+	for _, f := range existing {
+		defined[f.GetName()] = true
+	}
+
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:   "config",
+			EnvVar: "MIGRATAUR_CONFIG",
+			Usage:  fmt.Sprintf("Path of the YAML or TOML config file, defaults to the first of %v found", defaultConfigFiles),
+		},
+	}
+
+	for _, s := range settings {
+		if !defined[s.flag] {
+			flags = append(flags, cli.StringFlag{Name: s.flag, EnvVar: s.env, Usage: s.usage})
+		}
+	}
+
+	return flags
+}
+
+// loadConfig reads the config file, if any, and applies environment variables and global
+// flags on top of it.
+func loadConfig(c *cli.Context) (*config, error) {
+	conf := &config{}
+	path := c.GlobalString("config")
+
+	if path == "" {
+		for _, candidate := range defaultConfigFiles {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+
+	if path != "" {
+		if err := readConfigFile(path, conf); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			*s.value(conf) = value
+		}
+
+		if c.GlobalIsSet(s.flag) {
+			*s.value(conf) = c.GlobalString(s.flag)
+		}
+	}
+
+	if conf.Driver != "" && !isRegistered(conf.Driver) {
+		return nil, fmt.Errorf("the driver %s is not available, use one of %s or write your own main with cmd.For", conf.Driver, strings.Join(sql.Drivers(), ", "))
+	}
+
+	return conf, nil
+}
+
+// isRegistered checks if a database/sql driver with the given name has been imported
+func isRegistered(driver string) bool {
+	for _, name := range sql.Drivers() {
+		if name == driver {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readConfigFile decodes the given file depending on its extension
+func readConfigFile(path string, conf *config) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, conf)
+	case ".toml":
+		err = toml.Unmarshal(data, conf)
+	default:
+		return fmt.Errorf("the config file %s should be a .yml, .yaml or .toml one", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read the config file %s: %s", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+// runLoadConfig parses the given global flags like the migrataur command does and loads
+// the config from them.
+func runLoadConfig(args ...string) (*config, error) {
+	var (
+		conf *config
+		err  error
+	)
+
+	// --dir is defined by the cmd package
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{cli.StringFlag{Name: "dir"}}
+	app.Flags = append(app.Flags, configFlags(app.Flags)...)
+	app.Action = func(c *cli.Context) error {
+		conf, err = loadConfig(c)
+		return nil
+	}
+
+	if runErr := app.Run(append([]string{"migrataur"}, args...)); runErr != nil {
+		return nil, runErr
+	}
+
+	return conf, err
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrataur")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	files := map[string]string{
+		"config.yml": `driver: postgres
+dsn: from-yaml
+directory: ./db
+markers:
+  up_start: -- +up
+`,
+		"config.toml": `driver = "postgres"
+dsn = "from-toml"
+directory = "./db"
+
+[markers]
+up_start = "-- +up"
+`,
+		"config.json":            `{"dsn": "from-json"}`,
+		"default/migrataur.yaml": "dsn: from-default\n",
+		"empty/.keep":            "",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		cwd      string
+		env      map[string]string
+		args     []string
+		expected config
+		err      string
+	}{
+		{
+			name:     "yaml file",
+			args:     []string{"--config", filepath.Join(dir, "config.yml")},
+			expected: config{Driver: "postgres", DSN: "from-yaml", Directory: "./db", Markers: markers{UpStart: "-- +up"}},
+		},
+		{
+			name:     "toml file",
+			args:     []string{"--config", filepath.Join(dir, "config.toml")},
+			expected: config{Driver: "postgres", DSN: "from-toml", Directory: "./db", Markers: markers{UpStart: "-- +up"}},
+		},
+		{
+			name:     "default file",
+			cwd:      "default",
+			expected: config{DSN: "from-default"},
+		},
+		{
+			name:     "no file",
+			cwd:      "empty",
+			expected: config{},
+		},
+		{
+			name:     "env over file",
+			env:      map[string]string{"MIGRATAUR_DSN": "from-env"},
+			args:     []string{"--config", filepath.Join(dir, "config.yml")},
+			expected: config{Driver: "postgres", DSN: "from-env", Directory: "./db", Markers: markers{UpStart: "-- +up"}},
+		},
+		{
+			name:     "flag over env",
+			env:      map[string]string{"MIGRATAUR_DSN": "from-env", "MIGRATAUR_DIR": "./env"},
+			args:     []string{"--config", filepath.Join(dir, "config.yml"), "--dsn", "from-flag", "--dir", "./flag"},
+			expected: config{Driver: "postgres", DSN: "from-flag", Directory: "./flag", Markers: markers{UpStart: "-- +up"}},
+		},
+		{
+			name: "unknown driver",
+			args: []string{"--config", filepath.Join(dir, "config.yml"), "--driver", "mysql"},
+			err:  "the driver mysql is not available, use one of postgres",
+		},
+		{
+			name: "unsupported extension",
+			args: []string{"--config", filepath.Join(dir, "config.json")},
+			err:  "should be a .yml, .yaml or .toml one",
+		},
+	}
+
+	for _, test := range tests {
+		if err := os.Chdir(filepath.Join(dir, test.cwd)); err != nil {
+			t.Fatal(err)
+		}
+
+		for key, value := range test.env {
+			os.Setenv(key, value)
+		}
+
+		conf, err := runLoadConfig(test.args...)
+
+		for key := range test.env {
+			os.Unsetenv(key)
+		}
+
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", test.name, test.err, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", test.name, err)
+			continue
+		}
+
+		if *conf != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, *conf)
+		}
+	}
+}
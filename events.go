@@ -0,0 +1,279 @@
+package migrataur
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Level distinguishes informational events from warnings and failures.
+type Level int
+
+const (
+	// LevelInfo for events describing what is going on
+	LevelInfo Level = iota
+	// LevelWarn for events which deserve attention but did not fail
+	LevelWarn
+	// LevelError for failures
+	LevelError
+	// LevelDebug for events only useful when troubleshooting, such as executed commands
+	LevelDebug Level = -1
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// EventKind tells what an Event is about.
+type EventKind int
+
+const (
+	// EventStep is emitted when a new operation starts
+	EventStep EventKind = iota
+	// EventInfo gives details about the current operation
+	EventInfo
+	// EventWarning is emitted when something deserves attention
+	EventWarning
+	// EventFailure is emitted when something failed, Err is always set
+	EventFailure
+	// EventMigrationCreated is emitted when a migration file has been written
+	EventMigrationCreated
+	// EventMigrationDeleted is emitted when a migration file has been removed
+	EventMigrationDeleted
+	// EventMigrationApplied is emitted when a migration has been applied or rolled back,
+	// depending on its Direction
+	EventMigrationApplied
+	// EventMigrationSkipped is emitted when a hook skipped a migration
+	EventMigrationSkipped
+	// EventCommand is emitted, at the debug level, with the command about to be executed
+	EventCommand
+)
+
+var eventKindNames = []string{"step", "info", "warning", "failure", "created", "deleted", "applied", "skipped", "command"}
+
+func (k EventKind) String() string {
+	if int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+
+	return "unknown"
+}
+
+// Event is a structured log entry emitted by a Migrataur instance.
+type Event struct {
+	Kind  EventKind
+	Level Level
+	// Message is a human readable description of the event
+	Message string
+	// Migration is the name of the migration concerned, if any
+	Migration string
+	// Direction and Duration are only meaningful for migrations events
+	Direction Direction
+	Duration  time.Duration
+	// Fake is true when a migration has only been recorded in the history
+	Fake bool
+	Err  error
+	// Tenant is set when the event comes from a TenantRunner
+	Tenant string
+}
+
+// EventHandler receives every event emitted by a Migrataur instance.
+type EventHandler interface {
+	Handle(Event)
+}
+
+// EventHandlerFunc is an adapter to use ordinary functions as EventHandler.
+type EventHandlerFunc func(Event)
+
+// Handle calls f(e).
+func (f EventHandlerFunc) Handle(e Event) { f(e) }
+
+// PrintfHandler writes events to the given Logger in the human friendly format migrataur
+// has always used. This is the handler used when Options.EventHandler is not set.
+func PrintfHandler(logger Logger) EventHandler {
+	return EventHandlerFunc(func(e Event) {
+		logger.Printf("%s", FormatEvent(e))
+	})
+}
+
+// ColoredPrintfHandler does the same as PrintfHandler but colors lines with ANSI escape
+// codes depending on the event: green for success, yellow for warnings and red for failures.
+func ColoredPrintfHandler(logger Logger) EventHandler {
+	return EventHandlerFunc(func(e Event) {
+		line := FormatEvent(e)
+
+		if color := eventColor(e); color != "" {
+			line = color + line + "\033[0m"
+		}
+
+		logger.Printf("%s", line)
+	})
+}
+
+func eventColor(e Event) string {
+	switch {
+	case e.Level == LevelError:
+		return "\033[31m"
+	case e.Level == LevelWarn:
+		return "\033[33m"
+	case e.Level == LevelDebug:
+		return "\033[90m"
+	case e.Kind == EventMigrationApplied, e.Kind == EventMigrationDeleted:
+		return "\033[32m"
+	default:
+		return ""
+	}
+}
+
+// jsonEvent is how an Event is written by JSONHandler
+type jsonEvent struct {
+	Time       time.Time `json:"time"`
+	Level      string    `json:"level"`
+	Kind       string    `json:"kind"`
+	Message    string    `json:"message,omitempty"`
+	Migration  string    `json:"migration,omitempty"`
+	Direction  string    `json:"direction,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Fake       bool      `json:"fake,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Tenant     string    `json:"tenant,omitempty"`
+}
+
+// JSONHandler writes events to w as JSON objects, one per line, for log collectors.
+func JSONHandler(w io.Writer) EventHandler {
+	encoder := json.NewEncoder(w)
+
+	return EventHandlerFunc(func(e Event) {
+		entry := jsonEvent{
+			Time:       time.Now().UTC(),
+			Level:      e.Level.String(),
+			Kind:       e.Kind.String(),
+			Message:    e.Message,
+			Migration:  e.Migration,
+			DurationMs: int64(e.Duration / time.Millisecond),
+			Fake:       e.Fake,
+			Tenant:     e.Tenant,
+		}
+
+		switch e.Kind {
+		case EventMigrationApplied, EventMigrationSkipped, EventCommand, EventFailure:
+			if e.Migration != "" {
+				entry.Direction = e.Direction.String()
+			}
+		}
+
+		if e.Err != nil {
+			entry.Error = strings.TrimSpace(e.Err.Error())
+		}
+
+		encoder.Encode(entry)
+	})
+}
+
+// FormatEvent formats an event as a single human friendly line, prefixed by its tenant if any.
+func FormatEvent(e Event) string {
+	if e.Tenant != "" {
+		return fmt.Sprintf("[%s] %s", e.Tenant, formatEvent(e))
+	}
+
+	return formatEvent(e)
+}
+
+func formatEvent(e Event) string {
+	switch e.Kind {
+	case EventStep:
+		return e.Message
+	case EventWarning:
+		return "⚠\t" + e.Message
+	case EventFailure:
+		if e.Migration != "" {
+			return fmt.Sprintf("✗\t%s: %s", e.Migration, e.Err)
+		}
+
+		return "✗\t" + strings.TrimSpace(e.Err.Error())
+	case EventMigrationCreated:
+		return fmt.Sprintf("\t%s created!", e.Migration)
+	case EventMigrationDeleted:
+		return fmt.Sprintf("✓\t%s deleted!", e.Migration)
+	case EventMigrationApplied:
+		if e.Fake {
+			return fmt.Sprintf("✓\t%s (recorded in the history, commands were NOT executed)", e.Migration)
+		}
+
+		return "✓\t" + e.Migration
+	case EventMigrationSkipped:
+		return fmt.Sprintf("-\t%s skipped", e.Migration)
+	case EventCommand:
+		return fmt.Sprintf("\t%s %s:\n\t\t%s", e.Migration, e.Direction, strings.Replace(strings.TrimSpace(e.Message), "\n", "\n\t\t", -1))
+	default:
+		return "\t" + e.Message
+	}
+}
+
+// emit sends the event to the configured EventHandler or, if none, to the Logger, unless
+// its level is below Options.MinLevel
+func (m *Migrataur) emit(e Event) {
+	switch e.Kind {
+	case EventCommand:
+		e.Level = LevelDebug
+	case EventWarning, EventMigrationSkipped:
+		e.Level = LevelWarn
+	case EventFailure:
+		e.Level = LevelError
+	default:
+		e.Level = LevelInfo
+	}
+
+	if e.Level < m.options.MinLevel {
+		return
+	}
+
+	m.handle(e)
+}
+
+// handle sends the event as is
+func (m *Migrataur) handle(e Event) {
+	if m.options.EventHandler != nil {
+		m.options.EventHandler.Handle(e)
+	} else if m.options.Logger != nil {
+		PrintfHandler(m.options.Logger).Handle(e)
+	}
+}
+
+// step emits an EventStep
+func (m *Migrataur) step(format string, args ...interface{}) {
+	m.emit(Event{Kind: EventStep, Message: fmt.Sprintf(format, args...)})
+}
+
+// info emits an EventInfo
+func (m *Migrataur) info(format string, args ...interface{}) {
+	m.emit(Event{Kind: EventInfo, Message: fmt.Sprintf(format, args...)})
+}
+
+// warn emits an EventWarning
+func (m *Migrataur) warn(format string, args ...interface{}) {
+	m.emit(Event{Kind: EventWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// fail emits an EventFailure and returns the given error
+func (m *Migrataur) fail(err error) error {
+	m.emit(Event{Kind: EventFailure, Message: strings.TrimSpace(err.Error()), Err: err})
+
+	return err
+}
+
+// migrationEvent emits an event concerning the given migration
+func (m *Migrataur) migrationEvent(kind EventKind, migration *Migration, message string) {
+	m.emit(Event{Kind: kind, Migration: migration.Name, Message: message})
+}
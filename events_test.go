@@ -0,0 +1,160 @@
+package migrataur
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFormatEvent(t *testing.T) {
+	assert := assert(t)
+
+	assert.
+		equals("Applying all pending migrations", FormatEvent(Event{Kind: EventStep, Message: "Applying all pending migrations"})).
+		equals("\tAll clear, nothing done!", FormatEvent(Event{Kind: EventInfo, Message: "All clear, nothing done!"})).
+		equals("⚠\tcareful", FormatEvent(Event{Kind: EventWarning, Message: "careful"})).
+		equals("✗\tboom", FormatEvent(Event{Kind: EventFailure, Err: fmt.Errorf("\tboom")})).
+		equals("✗\tmigration01.sql: boom", FormatEvent(Event{Kind: EventFailure, Migration: "migration01.sql", Err: fmt.Errorf("boom")})).
+		equals("\tmigration01.sql created!", FormatEvent(Event{Kind: EventMigrationCreated, Migration: "migration01.sql"})).
+		equals("✓\tmigration01.sql deleted!", FormatEvent(Event{Kind: EventMigrationDeleted, Migration: "migration01.sql"})).
+		equals("✓\tmigration01.sql", FormatEvent(Event{Kind: EventMigrationApplied, Migration: "migration01.sql"})).
+		equals("✓\tmigration01.sql (recorded in the history, commands were NOT executed)", FormatEvent(Event{Kind: EventMigrationApplied, Migration: "migration01.sql", Fake: true})).
+		equals("-\tmigration01.sql skipped", FormatEvent(Event{Kind: EventMigrationSkipped, Migration: "migration01.sql"})).
+		equals("\tmigration01.sql up:\n\t\tcreate table a;\n\t\tcreate table b;", FormatEvent(Event{Kind: EventCommand, Migration: "migration01.sql", Direction: Up, Message: "create table a;\ncreate table b;\n"}))
+}
+
+func TestMigrataurEventHandler(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	events := []Event{}
+
+	opts := DefaultOptions
+	opts.EventHandler = EventHandlerFunc(func(e Event) {
+		events = append(events, e)
+	})
+
+	instance := New(failingAdapter{newMockAdapter()}, opts)
+	_, err := instance.MigrateToLatest()
+
+	assert.
+		notNil(err).
+		equals(2, len(events)).
+		equals(EventStep, events[0].Kind).
+		equals(LevelInfo, events[0].Level).
+		equals(EventFailure, events[1].Kind).
+		equals(LevelError, events[1].Level).
+		equals("migration01.sql", events[1].Migration).
+		equals(Up, events[1].Direction).
+		notNil(events[1].Err)
+
+	events = events[:0]
+	instance = New(newMockAdapter(), opts)
+	_, err = instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	applied := []Event{}
+
+	for _, e := range events {
+		if e.Kind == EventMigrationApplied {
+			applied = append(applied, e)
+		}
+	}
+
+	assert.
+		equals(2, len(applied)).
+		equals("migration01.sql", applied[0].Migration).
+		equals("migration02.sql", applied[1].Migration).
+		true(applied[0].Duration >= time.Duration(0))
+}
+
+func TestMigrataurOverrides(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql", content: `-- +migrataur up
+create table a;
+-- -migrataur up
+
+-- +migrataur down
+drop table a;
+-- -migrataur down`},
+	)
+
+	assert := assert(t)
+	events := []Event{}
+	handler := EventHandlerFunc(func(e Event) {
+		events = append(events, e)
+	})
+
+	instance := New(newMockAdapter(), DefaultOptions)
+	set, err := instance.AddSet("plugins", newMockAdapter(), Options{Directory: "plugins"})
+
+	assert.nil(err)
+
+	instance.OverrideEventHandler(handler)
+	instance.OverrideMinLevel(LevelWarn)
+
+	assert.
+		notNil(set.Options().EventHandler).
+		equals(LevelWarn, set.Options().MinLevel)
+
+	_, err = instance.MigrateToLatest()
+	instance.Printf("result")
+
+	assert.
+		nil(err).
+		equals(1, len(events)).
+		equals("result", events[0].Message)
+
+	events = events[:0]
+	instance.OverrideMinLevel(LevelDebug)
+	_, err = instance.Rollback("migration01")
+
+	commands := filterEvents(events, EventCommand)
+
+	assert.
+		nil(err).
+		equals(1, len(commands)).
+		equals(LevelDebug, commands[0].Level).
+		contains("drop table a;", commands[0].Message)
+
+	absPath, _ := filepath.Abs("other")
+
+	assert.
+		notNil(set.OverrideDirectory("")).
+		nil(set.OverrideDirectory("other")).
+		equals(absPath, set.Options().Directory).
+		notEquals(absPath, instance.Options().Directory)
+
+	var output bytes.Buffer
+	instance.OverrideEventHandler(JSONHandler(&output))
+	_, err = instance.MigrateToLatest()
+
+	var entry map[string]interface{}
+	lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+
+	assert.
+		nil(err).
+		nil(json.Unmarshal(lines[len(lines)-1], &entry)).
+		equals("applied", entry["kind"]).
+		equals("migration01.sql", entry["migration"]).
+		equals("up", entry["direction"])
+}
+
+func filterEvents(events []Event, kind EventKind) []Event {
+	result := []Event{}
+
+	for _, e := range events {
+		if e.Kind == kind {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
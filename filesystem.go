@@ -13,9 +13,13 @@ var fsAdapter fileSystem = osFileSystem{}
 type fileSystem interface {
 	MkdirAll(path string, mode os.FileMode) error
 	Create(path string) (file, error)
+	// CreateExclusive creates the file, failing with an error satisfying os.IsExist if it
+	// already exists
+	CreateExclusive(path string) (file, error)
 	Remove(path string) error
 	ReadDir(dirname string) ([]os.FileInfo, error)
 	ReadFile(filename string) ([]byte, error)
+	Rename(oldpath, newpath string) error
 }
 
 type file interface {
@@ -26,8 +30,27 @@ type file interface {
 // osFileSystem implements the fileSystem interface using the os provider
 type osFileSystem struct{}
 
-func (osFileSystem) MkdirAll(path string, mode os.FileMode) error  { return os.MkdirAll(path, mode) }
-func (osFileSystem) Create(path string) (file, error)              { return os.Create(path) }
-func (osFileSystem) Remove(path string) error                      { return os.Remove(path) }
+func (osFileSystem) MkdirAll(path string, mode os.FileMode) error { return os.MkdirAll(path, mode) }
+func (osFileSystem) Create(path string) (file, error)             { return os.Create(path) }
+func (osFileSystem) Remove(path string) error                     { return os.Remove(path) }
+func (osFileSystem) CreateExclusive(path string) (file, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+}
 func (osFileSystem) ReadDir(dirname string) ([]os.FileInfo, error) { return ioutil.ReadDir(dirname) }
 func (osFileSystem) ReadFile(filename string) ([]byte, error)      { return ioutil.ReadFile(filename) }
+func (osFileSystem) Rename(oldpath, newpath string) error          { return os.Rename(oldpath, newpath) }
+
+// writeFile writes raw data to the given path, truncating the file if it already exists
+func writeFile(path string, data []byte) error {
+	f, err := fsAdapter.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = f.Write(data)
+
+	return err
+}
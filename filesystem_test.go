@@ -10,6 +10,14 @@ import (
 // mockFSAdapter represents a mock for the filesystem
 var mockFSAdapter = &mockFileSystem{}
 
+// mockMigrationContent is returned by ReadFile for files without an explicit content
+const mockMigrationContent = `-- +migrataur up
+-- up
+-- -migrataur up
+-- +migrataur down
+-- down
+-- -migrataur down`
+
 func TestMain(m *testing.M) {
 	// Replace the fsAdapter by the mock and run the test suite
 	oldFs := fsAdapter
@@ -27,9 +35,25 @@ type mockFileSystem struct {
 }
 
 func (fs *mockFileSystem) Create(path string) (file, error) {
-	fs.files = append(fs.files, mockFileInfo{name: filepath.Base(path)})
+	name := filepath.Base(path)
+
+	// Just like os.Create, truncate the file if it already exists
+	fs.Remove(path)
+	fs.files = append(fs.files, mockFileInfo{name: name})
+
+	return mockFile{fs: fs, name: name}, nil
+}
+
+func (fs *mockFileSystem) CreateExclusive(path string) (file, error) {
+	name := filepath.Base(path)
+
+	for _, f := range fs.files {
+		if f.Name() == name {
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrExist}
+		}
+	}
 
-	return mockFile{}, nil
+	return fs.Create(path)
 }
 
 func (fs *mockFileSystem) Remove(path string) error {
@@ -44,9 +68,38 @@ func (fs *mockFileSystem) Remove(path string) error {
 	return nil
 }
 
+func (fs *mockFileSystem) Rename(oldpath, newpath string) error {
+	oldName, newName := filepath.Base(oldpath), filepath.Base(newpath)
+
+	for i, f := range fs.files {
+		if info, ok := f.(mockFileInfo); ok && info.name == oldName {
+			info.name = newName
+			fs.files[i] = info
+			return nil
+		}
+	}
+
+	return &os.PathError{Op: "rename", Path: oldpath, Err: os.ErrNotExist}
+}
+
 func (*mockFileSystem) MkdirAll(path string, mode os.FileMode) error     { return nil }
 func (fs *mockFileSystem) ReadDir(dirname string) ([]os.FileInfo, error) { return fs.files, nil }
-func (fs *mockFileSystem) ReadFile(filename string) ([]byte, error)      { return []byte{}, nil }
+
+func (fs *mockFileSystem) ReadFile(filename string) ([]byte, error) {
+	name := filepath.Base(filename)
+
+	for _, f := range fs.files {
+		if info, ok := f.(mockFileInfo); ok && info.name == name {
+			if info.content == "" {
+				return []byte(mockMigrationContent), nil
+			}
+
+			return []byte(info.content), nil
+		}
+	}
+
+	return []byte{}, nil
+}
 
 // empty the filesystem adapter
 func (fs *mockFileSystem) empty() {
@@ -75,15 +128,38 @@ func (fs *mockFileSystem) exists(name string) bool {
 	return false
 }
 
-type mockFile struct{}
+// mockFile appends written data to the content of the file in the mock filesystem
+type mockFile struct {
+	fs   *mockFileSystem
+	name string
+}
 
-func (mockFile) Close() error                   { return nil }
-func (mockFile) Write(data []byte) (int, error) { return len(data), nil }
+func (mockFile) Close() error { return nil }
+
+func (f mockFile) Write(data []byte) (int, error) {
+	for i, info := range f.fs.files {
+		if mockInfo, ok := info.(mockFileInfo); ok && mockInfo.name == f.name {
+			mockInfo.content += string(data)
+			f.fs.files[i] = mockInfo
+			break
+		}
+	}
+
+	return len(data), nil
+}
+
+// content retrieves the content of the file with the given name
+func (fs *mockFileSystem) content(name string) string {
+	data, _ := fs.ReadFile(name)
+
+	return string(data)
+}
 
 type mockFileInfo struct {
-	name string
-	size int64
-	dir  bool
+	name    string
+	size    int64
+	dir     bool
+	content string // Returned by ReadFile
 }
 
 func (m mockFileInfo) Name() string     { return m.name }
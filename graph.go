@@ -0,0 +1,65 @@
+package migrataur
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortByDependencies orders given names so that each one comes after its dependencies.
+// When there is no constraint between two names, their relative order in the given slice
+// is kept. kind is only used to build meaningful error messages.
+func sortByDependencies(kind string, names []string, dependencies map[string][]string) ([]string, error) {
+	pending := map[string]int{}
+	dependents := map[string][]string{}
+
+	for _, name := range names {
+		pending[name] = 0
+	}
+
+	for _, name := range names {
+		for _, dep := range dependencies[name] {
+			if _, ok := pending[dep]; !ok {
+				return nil, fmt.Errorf("the %s %s depends on %s which does not exist", kind, name, dep)
+			}
+
+			pending[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	result := make([]string, 0, len(names))
+	done := map[string]bool{}
+
+	for len(result) < len(names) {
+		next := ""
+
+		// Always pick the first available name to preserve the initial order
+		for _, name := range names {
+			if !done[name] && pending[name] == 0 {
+				next = name
+				break
+			}
+		}
+
+		if next == "" {
+			remaining := []string{}
+
+			for _, name := range names {
+				if !done[name] {
+					remaining = append(remaining, name)
+				}
+			}
+
+			return nil, fmt.Errorf("dependency cycle detected between %ss %s", kind, strings.Join(remaining, ", "))
+		}
+
+		done[next] = true
+		result = append(result, next)
+
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package migrataur
+
+import "fmt"
+
+// UpgradeHistory brings the history table up to date when it has been created by an older
+// version of the adapter, then rewrites rows of squashed migrations. The adapter must
+// implement HistoryUpgrader.
+func (m *Migrataur) UpgradeHistory() error {
+	m.step("Upgrading the history")
+
+	upgrader, ok := m.adapter.(HistoryUpgrader)
+
+	if !ok {
+		return m.fail(fmt.Errorf("\tThe adapter does not support history upgrades"))
+	}
+
+	if err := upgrader.UpgradeHistory(); err != nil {
+		return m.fail(err)
+	}
+
+	migrations, _, err := m.getAllMigrationsAndOrphans(Up)
+
+	if err != nil {
+		return m.fail(err)
+	}
+
+	if err = m.reconcileHistory(migrations); err != nil {
+		return m.fail(err)
+	}
+
+	for _, mig := range migrations {
+		if mig.IsPartiallyApplied() {
+			m.warn("Only some of the migrations squashed into %s have been applied, its history could not be rewritten", mig.Name)
+		}
+	}
+
+	m.info("History is up to date!")
+
+	return nil
+}
+
+// resolveStaleRows marks the migration as applied when the history holds a row for one of
+// its previous names or rows for every migration squashed into it, or as partially applied
+// when it holds only some of the squashed ones.
+func resolveStaleRows(mig *Migration) {
+	if len(mig.staleRows) == 0 || mig.recorded {
+		return
+	}
+
+	squashed, renamed := 0, false
+	latest := mig.staleRows[0]
+
+	for _, row := range mig.staleRows {
+		if containsName(mig.replaces, row.Name) {
+			squashed++
+		} else {
+			renamed = true
+		}
+
+		if row.AppliedAt.After(*latest.AppliedAt) {
+			latest = row
+		}
+	}
+
+	if !renamed && squashed != len(mig.replaces) {
+		mig.partial = true
+		return
+	}
+
+	mig.hasBeenAppliedLike(latest)
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reconcileHistory rewrites stale rows of the given migrations: a row is written for each
+// migration seen as applied thanks to them and they are removed. Partially applied ones are
+// left as is. This is how databases catch up with migrations squashed or renamed elsewhere.
+func (m *Migrataur) reconcileHistory(migrations []*Migration) error {
+	for _, mig := range migrations {
+		if len(mig.staleRows) == 0 || mig.partial {
+			continue
+		}
+
+		if !mig.recorded {
+			m.emit(Event{
+				Kind:      EventInfo,
+				Message:   fmt.Sprintf("Marking %s as applied since it was applied under another name", mig.Name),
+				Migration: mig.Name,
+			})
+
+			if err := m.adapter.MigrationApplied(mig); err != nil {
+				return err
+			}
+
+			mig.recorded = true
+		}
+
+		for _, row := range mig.staleRows {
+			if err := m.adapter.MigrationRollbacked(row); err != nil {
+				return err
+			}
+		}
+
+		mig.staleRows = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package migrataur
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSkipMigration may be returned by a BeforeMigration hook to skip a migration without
+// failing the whole run.
+var ErrSkipMigration = errors.New("skip this migration")
+
+// HookEvent holds what hooks need to know about a single migration.
+type HookEvent struct {
+	Migration *Migration
+	Direction Direction
+	// Duration is the time taken to run the migration, not set for BeforeMigration
+	Duration time.Duration
+	// Err is only set for OnError
+	Err error
+}
+
+// RunEvent holds what hooks need to know about a whole run.
+type RunEvent struct {
+	Direction Direction
+	// Migrations are those which will be run for BeforeRun and those effectively run
+	// for AfterRun
+	Migrations []*Migration
+	// Duration and Err are only set for AfterRun
+	Duration time.Duration
+	Err      error
+}
+
+// Hooks holds functions called while running migrations, every one of them is optional.
+type Hooks struct {
+	// BeforeRun is called before running migrations, returning an error aborts the run.
+	BeforeRun func(RunEvent) error
+	// AfterRun is called once a run is over, successful or not.
+	AfterRun func(RunEvent)
+	// BeforeMigration is called before each migration. Returning ErrSkipMigration skips it,
+	// any other error vetoes it and aborts the run.
+	BeforeMigration func(HookEvent) error
+	// AfterMigration is called after each migration successfully applied or rolled back.
+	AfterMigration func(HookEvent)
+	// OnError is called when a migration fails or has been vetoed.
+	OnError func(HookEvent)
+}
+
+// AddHooks registers hooks on this instance, called after those already registered. For
+// BeforeRun and BeforeMigration, the first error returned stops the chain.
+func (m *Migrataur) AddHooks(hooks Hooks) {
+	m.options.Hooks = chainHooks(m.options.Hooks, hooks)
+}
+
+// chainHooks combines two sets of hooks, calling first ones before
+func chainHooks(first, then Hooks) Hooks {
+	return Hooks{
+		BeforeRun: func(e RunEvent) error {
+			if first.BeforeRun != nil {
+				if err := first.BeforeRun(e); err != nil {
+					return err
+				}
+			}
+
+			if then.BeforeRun != nil {
+				return then.BeforeRun(e)
+			}
+
+			return nil
+		},
+		AfterRun: func(e RunEvent) {
+			if first.AfterRun != nil {
+				first.AfterRun(e)
+			}
+
+			if then.AfterRun != nil {
+				then.AfterRun(e)
+			}
+		},
+		BeforeMigration: func(e HookEvent) error {
+			if first.BeforeMigration != nil {
+				if err := first.BeforeMigration(e); err != nil {
+					return err
+				}
+			}
+
+			if then.BeforeMigration != nil {
+				return then.BeforeMigration(e)
+			}
+
+			return nil
+		},
+		AfterMigration: func(e HookEvent) {
+			if first.AfterMigration != nil {
+				first.AfterMigration(e)
+			}
+
+			if then.AfterMigration != nil {
+				then.AfterMigration(e)
+			}
+		},
+		OnError: func(e HookEvent) {
+			if first.OnError != nil {
+				first.OnError(e)
+			}
+
+			if then.OnError != nil {
+				then.OnError(e)
+			}
+		},
+	}
+}
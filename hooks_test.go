@@ -0,0 +1,111 @@
+package migrataur
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMigrataurHooks(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+	)
+
+	assert := assert(t)
+	events := []string{}
+
+	opts := DefaultOptions
+	opts.Hooks = Hooks{
+		BeforeRun: func(e RunEvent) error {
+			events = append(events, fmt.Sprintf("before run %s %d", e.Direction, len(e.Migrations)))
+			return nil
+		},
+		AfterRun: func(e RunEvent) {
+			events = append(events, fmt.Sprintf("after run %s %d %t", e.Direction, len(e.Migrations), e.Err == nil))
+		},
+		BeforeMigration: func(e HookEvent) error {
+			if strings.Contains(e.Migration.Name, "migration02") {
+				return ErrSkipMigration
+			}
+
+			if strings.Contains(e.Migration.Name, "migration03") && e.Direction == Down {
+				return fmt.Errorf("vetoed")
+			}
+
+			return nil
+		},
+		AfterMigration: func(e HookEvent) {
+			events = append(events, fmt.Sprintf("after %s %s", e.Migration.Name, e.Direction))
+		},
+		OnError: func(e HookEvent) {
+			events = append(events, fmt.Sprintf("error %s %s %s", e.Migration.Name, e.Direction, e.Err))
+		},
+	}
+
+	instance := New(newMockAdapter(), opts)
+	applied, err := instance.MigrateToLatest()
+
+	assert.
+		nil(err).
+		applied(applied, "migration01", "migration03")
+
+	_, err = instance.Reset()
+
+	assert.
+		notNil(err).
+		equals(7, len(events)).
+		equals("before run up 3", events[0]).
+		equals("after migration01.sql up", events[1]).
+		equals("after migration03.sql up", events[2]).
+		equals("after run up 2 true", events[3]).
+		equals("before run down 3", events[4]).
+		equals("error migration03.sql down vetoed", events[5]).
+		equals("after run down 0 false", events[6])
+}
+
+func TestMigrataurAddHooks(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	events := []string{}
+
+	opts := DefaultOptions
+	opts.Hooks = Hooks{
+		BeforeMigration: func(e HookEvent) error {
+			if strings.Contains(e.Migration.Name, "migration02") {
+				return ErrSkipMigration
+			}
+
+			return nil
+		},
+		AfterMigration: func(e HookEvent) {
+			events = append(events, "first "+e.Migration.Name)
+		},
+	}
+
+	instance := New(newMockAdapter(), opts)
+	instance.AddHooks(Hooks{
+		BeforeMigration: func(e HookEvent) error {
+			events = append(events, "before "+e.Migration.Name)
+			return nil
+		},
+		AfterMigration: func(e HookEvent) {
+			events = append(events, "then "+e.Migration.Name)
+		},
+	})
+
+	applied, err := instance.MigrateToLatest()
+
+	assert.
+		nil(err).
+		applied(applied, "migration01").
+		equals(3, len(events)).
+		equals("before migration01.sql", events[0]).
+		equals("first migration01.sql", events[1]).
+		equals("then migration01.sql", events[2])
+}
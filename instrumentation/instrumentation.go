@@ -0,0 +1,201 @@
+// Package instrumentation reports migrataur runs to metrics and tracing backends. It only
+// depends on small interfaces so any backend can be plugged in: Prometheus counters, gauges
+// and histograms satisfy them as is, tracers need a thin wrapper.
+package instrumentation
+
+import (
+	"sync"
+
+	"github.com/YuukanOO/migrataur"
+)
+
+// Counter is a monotonic metric, such as a prometheus.Counter.
+type Counter interface {
+	Inc()
+}
+
+// Gauge is a metric which can go up and down, such as a prometheus.Gauge.
+type Gauge interface {
+	Set(float64)
+}
+
+// Histogram samples observations, such as a prometheus.Histogram.
+type Histogram interface {
+	Observe(float64)
+}
+
+// Metrics holds metrics updated by an instrumented instance, every one of them is optional.
+type Metrics struct {
+	// Applied, RolledBack and Failed count migrations
+	Applied    Counter
+	RolledBack Counter
+	Failed     Counter
+	// Duration observes, in seconds, the time taken by each migration applied or rolled back
+	Duration Histogram
+	// Pending is set to the number of pending migrations after each run
+	Pending Gauge
+}
+
+// Span represents a single traced operation, such as an OpenTelemetry span.
+type Span interface {
+	// RecordError marks the span as failed
+	RecordError(error)
+	End()
+}
+
+// Tracer starts spans, wrap an OpenTelemetry tracer to implement it.
+type Tracer interface {
+	Start(name string, attributes map[string]string) Span
+}
+
+// SpanName is the name of spans started for each migration
+const SpanName = "migrataur.migration"
+
+// Instrumentation reports what an instance does to the configured metrics and tracer.
+type Instrumentation struct {
+	instance *migrataur.Migrataur
+	metrics  Metrics
+	tracer   Tracer
+	mu       sync.Mutex
+	spans    map[*migrataur.Migration]Span
+	// errorHandler is given errors which could not be returned, see OnError
+	errorHandler func(error)
+}
+
+// Instrument registers hooks on the given instance to update metrics and start a span per
+// migration. tracer may be nil if you are only interested in metrics.
+func Instrument(instance *migrataur.Migrataur, metrics Metrics, tracer Tracer) *Instrumentation {
+	i := &Instrumentation{
+		instance: instance,
+		metrics:  metrics,
+		tracer:   tracer,
+		spans:    map[*migrataur.Migration]Span{},
+	}
+
+	instance.AddHooks(migrataur.Hooks{
+		BeforeMigration: i.beforeMigration,
+		AfterMigration:  i.afterMigration,
+		OnError:         i.onError,
+		AfterRun: func(migrataur.RunEvent) {
+			i.endSkippedSpans()
+
+			if err := i.UpdatePending(); err != nil {
+				i.handleError("Could not update the pending migrations gauge", err)
+			}
+		},
+	})
+
+	return i
+}
+
+// OnError sets the function given errors happening in hooks, such as failing to update the
+// Pending gauge after a run. Without it, they are emitted as warnings by the instance.
+func (i *Instrumentation) OnError(handler func(error)) *Instrumentation {
+	i.errorHandler = handler
+
+	return i
+}
+
+// UpdatePending sets the Pending gauge to the number of migrations not applied yet. It is
+// called after each run, call it at startup to report it before anything is applied.
+func (i *Instrumentation) UpdatePending() error {
+	if i.metrics.Pending == nil {
+		return nil
+	}
+
+	pending, err := i.instance.Pending()
+
+	if err != nil {
+		return err
+	}
+
+	i.metrics.Pending.Set(float64(len(pending)))
+
+	return nil
+}
+
+// handleError reports an error which could not be returned to the caller, message telling
+// what was being done when it happened
+func (i *Instrumentation) handleError(message string, err error) {
+	if i.errorHandler != nil {
+		i.errorHandler(err)
+		return
+	}
+
+	i.instance.Warnf("%s: %s", message, err)
+}
+
+func (i *Instrumentation) beforeMigration(e migrataur.HookEvent) error {
+	if i.tracer == nil {
+		return nil
+	}
+
+	span := i.tracer.Start(SpanName, map[string]string{
+		"migrataur.set":       i.instance.SetName(),
+		"migrataur.migration": e.Migration.Name,
+		"migrataur.direction": e.Direction.String(),
+	})
+
+	i.mu.Lock()
+	i.spans[e.Migration] = span
+	i.mu.Unlock()
+
+	return nil
+}
+
+func (i *Instrumentation) afterMigration(e migrataur.HookEvent) {
+	counter := i.metrics.Applied
+
+	if e.Direction == migrataur.Down {
+		counter = i.metrics.RolledBack
+	}
+
+	if counter != nil {
+		counter.Inc()
+	}
+
+	if i.metrics.Duration != nil {
+		i.metrics.Duration.Observe(e.Duration.Seconds())
+	}
+
+	if span := i.popSpan(e.Migration); span != nil {
+		span.End()
+	}
+}
+
+func (i *Instrumentation) onError(e migrataur.HookEvent) {
+	if i.metrics.Failed != nil {
+		i.metrics.Failed.Inc()
+	}
+
+	if span := i.popSpan(e.Migration); span != nil {
+		span.RecordError(e.Err)
+		span.End()
+	}
+}
+
+// popSpan retrieves and forgets the span started for the given migration, if any. A hook
+// registered before ours may have vetoed the migration so there is nothing to end. Those
+// registered after ours may skip it, see endSkippedSpans.
+func (i *Instrumentation) popSpan(migration *migrataur.Migration) Span {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	span := i.spans[migration]
+	delete(i.spans, migration)
+
+	return span
+}
+
+// endSkippedSpans ends spans still running once a run is over. They belong to migrations
+// skipped by a BeforeMigration hook registered after ours, which are neither followed by
+// AfterMigration nor OnError.
+func (i *Instrumentation) endSkippedSpans() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for migration, span := range i.spans {
+		span.End()
+		delete(i.spans, migration)
+	}
+}
@@ -0,0 +1,276 @@
+package instrumentation
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YuukanOO/migrataur"
+)
+
+// memoryAdapter keeps the history in memory and fails commands containing "fail"
+type memoryAdapter struct {
+	applied []*migrataur.Migration
+	// err is returned when retrieving the history if set
+	err error
+}
+
+func (a *memoryAdapter) GetInitialMigration() (up, down string) { return "-- up", "-- down" }
+
+func (a *memoryAdapter) MigrationApplied(migration *migrataur.Migration) error {
+	a.applied = append(a.applied, migration)
+	return nil
+}
+
+func (a *memoryAdapter) MigrationRollbacked(migration *migrataur.Migration) error {
+	for i, m := range a.applied {
+		if m.Name == migration.Name {
+			a.applied = append(a.applied[:i], a.applied[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (a *memoryAdapter) Exec(command string) error {
+	if strings.Contains(command, "fail") {
+		return errors.New("command failed")
+	}
+
+	return nil
+}
+
+func (a *memoryAdapter) GetAll() ([]*migrataur.Migration, error) { return a.applied, a.err }
+
+type counter struct{ value int }
+
+func (c *counter) Inc() { c.value++ }
+
+type gauge struct{ value float64 }
+
+func (g *gauge) Set(value float64) { g.value = value }
+
+type histogram struct{ observations []float64 }
+
+func (h *histogram) Observe(value float64) { h.observations = append(h.observations, value) }
+
+type span struct {
+	attributes map[string]string
+	err        error
+	ended      bool
+}
+
+func (s *span) RecordError(err error) { s.err = err }
+func (s *span) End()                  { s.ended = true }
+
+type tracer struct{ spans []*span }
+
+func (t *tracer) Start(name string, attributes map[string]string) Span {
+	s := &span{attributes: attributes}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func writeMigration(t *testing.T, dir, name, up string) {
+	content := "-- +migrataur up\n" + up + "\n-- -migrataur up\n\n\n-- +migrataur down\n-- down\n-- -migrataur down"
+
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrataur")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeMigration(t, dir, "migration01.sql", "-- ok")
+	writeMigration(t, dir, "migration02.sql", "-- ok")
+	writeMigration(t, dir, "migration03.sql", "-- fail")
+
+	applied, rolledBack, failed := &counter{}, &counter{}, &counter{}
+	pending, durations, spans := &gauge{}, &histogram{}, &tracer{}
+
+	instance := migrataur.New(&memoryAdapter{}, migrataur.Options{Directory: dir})
+	instrumentation := Instrument(instance, Metrics{
+		Applied:    applied,
+		RolledBack: rolledBack,
+		Failed:     failed,
+		Duration:   durations,
+		Pending:    pending,
+	}, spans)
+
+	if err := instrumentation.UpdatePending(); err != nil || pending.value != 3 {
+		t.Fatalf("expected 3 pending migrations, got %v (%v)", pending.value, err)
+	}
+
+	if _, err := instance.MigrateToLatest(); err == nil {
+		t.Fatal("expected migration03.sql to fail")
+	}
+
+	if _, err := instance.Rollback("migration02"); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied.value != 2 || rolledBack.value != 1 || failed.value != 1 {
+		t.Errorf("unexpected counters: applied %d, rolled back %d, failed %d", applied.value, rolledBack.value, failed.value)
+	}
+
+	if len(durations.observations) != 3 {
+		t.Errorf("expected 3 durations, got %d", len(durations.observations))
+	}
+
+	if pending.value != 2 {
+		t.Errorf("expected 2 pending migrations, got %v", pending.value)
+	}
+
+	if len(spans.spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans.spans))
+	}
+
+	for _, s := range spans.spans {
+		if !s.ended {
+			t.Errorf("span for %s has not been ended", s.attributes["migrataur.migration"])
+		}
+	}
+
+	if failedSpan := spans.spans[2]; failedSpan.err == nil || failedSpan.attributes["migrataur.migration"] != "migration03.sql" {
+		t.Errorf("expected the span of migration03.sql to record the error")
+	}
+
+	if s := spans.spans[3]; s.attributes["migrataur.direction"] != "down" || s.attributes["migrataur.set"] != migrataur.DefaultSetName {
+		t.Errorf("unexpected attributes %v", s.attributes)
+	}
+}
+
+func TestInstrumentPendingErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrataur")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeMigration(t, dir, "migration01.sql", "-- ok")
+
+	var (
+		events  []migrataur.Event
+		handled error
+	)
+
+	adapter := &memoryAdapter{}
+	instance := migrataur.New(adapter, migrataur.Options{
+		Directory:    dir,
+		EventHandler: migrataur.EventHandlerFunc(func(e migrataur.Event) { events = append(events, e) }),
+	})
+
+	// Registered before the instrumentation so the history is broken when the gauge is updated
+	instance.AddHooks(migrataur.Hooks{
+		AfterRun: func(migrataur.RunEvent) { adapter.err = errors.New("history unavailable") },
+	})
+
+	Instrument(instance, Metrics{Pending: &gauge{}}, nil).OnError(func(err error) { handled = err })
+
+	if _, err := instance.MigrateToLatest(); err != nil {
+		t.Fatal(err)
+	}
+
+	if handled == nil || handled.Error() != "history unavailable" {
+		t.Errorf("expected the error to be handled, got %v", handled)
+	}
+
+	for _, e := range events {
+		if strings.HasPrefix(e.Message, "Fetching migrations") {
+			t.Errorf("unexpected event %q", e.Message)
+		}
+	}
+}
+
+func TestInstrumentPendingErrorsAsWarnings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrataur")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeMigration(t, dir, "migration01.sql", "-- ok")
+
+	var warnings []migrataur.Event
+
+	adapter := &memoryAdapter{}
+	instance := migrataur.New(adapter, migrataur.Options{
+		Directory: dir,
+		EventHandler: migrataur.EventHandlerFunc(func(e migrataur.Event) {
+			if e.Kind == migrataur.EventWarning {
+				warnings = append(warnings, e)
+			}
+		}),
+	})
+
+	instance.AddHooks(migrataur.Hooks{
+		AfterRun: func(migrataur.RunEvent) { adapter.err = errors.New("history unavailable") },
+	})
+
+	Instrument(instance, Metrics{Pending: &gauge{}}, nil)
+
+	if _, err := instance.MigrateToLatest(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || warnings[0].Message != "Could not update the pending migrations gauge: history unavailable" {
+		t.Errorf("expected the error to be emitted as a warning, got %v", warnings)
+	}
+}
+
+func TestInstrumentSkippedMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrataur")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeMigration(t, dir, "migration01.sql", "-- ok")
+	writeMigration(t, dir, "migration02.sql", "-- ok")
+
+	spans := &tracer{}
+	instance := migrataur.New(&memoryAdapter{}, migrataur.Options{Directory: dir})
+	Instrument(instance, Metrics{}, spans)
+
+	// Registered after the instrumentation, so the span has already been started
+	instance.AddHooks(migrataur.Hooks{
+		BeforeMigration: func(e migrataur.HookEvent) error {
+			if e.Migration.Name == "migration02.sql" {
+				return migrataur.ErrSkipMigration
+			}
+
+			return nil
+		},
+	})
+
+	if _, err := instance.MigrateToLatest(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spans.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans.spans))
+	}
+
+	for _, s := range spans.spans {
+		if !s.ended {
+			t.Errorf("span for %s has not been ended", s.attributes["migrataur.migration"])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package migrataur
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultLockPollInterval is the time waited between two attempts to acquire the lock
+const DefaultLockPollInterval = time.Second
+
+// DefaultLockTimeout is the maximum time waited for the lock when Options.LockTimeout is not set
+const DefaultLockTimeout = time.Minute
+
+// acquireLock waits until the adapter lock is acquired, or timeout is reached if greater
+// than 0, and returns the function releasing it. Adapters which do not implement Locker
+// are not locked at all.
+func (m *Migrataur) acquireLock(pollInterval, timeout time.Duration) (func() error, error) {
+	locker, ok := m.adapter.(Locker)
+
+	if !ok {
+		m.warn("The adapter does not support locking, concurrent runs will not be prevented")
+		return func() error { return nil }, nil
+	}
+
+	if pollInterval <= 0 {
+		pollInterval = DefaultLockPollInterval
+	}
+
+	startedAt := time.Now()
+	waiting := false
+
+	for {
+		acquired, err := locker.TryLock()
+
+		if err != nil {
+			return nil, m.fail(err)
+		}
+
+		if acquired {
+			return locker.Unlock, nil
+		}
+
+		if timeout > 0 && time.Since(startedAt) >= timeout {
+			return nil, m.fail(fmt.Errorf("\tCould not acquire the lock after %s", timeout))
+		}
+
+		if !waiting {
+			m.info("Waiting for another instance to release the lock")
+			waiting = true
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
@@ -6,15 +6,36 @@ type MarshalOptions struct {
 	UpEnd     string
 	DownStart string
 	DownEnd   string
+	// DependsOn is the prefix of the header line listing, comma separated, the migrations
+	// that must be applied before this one. Leave it empty to disable dependencies.
+	DependsOn string
+	// Replaces is the prefix of the header line listing, comma separated, the migrations
+	// squashed into this one. It is written by Squash.
+	Replaces string
+	// Irreversible is the header line marking a migration as irreversible. Migrations with
+	// an empty down section are also considered irreversible.
+	Irreversible string
+	// Tags is the prefix of the header line listing, comma separated, tags of the migration
+	// which can then be selected with "tag:name".
+	Tags string
+	// RenamedFrom is the prefix of the header line listing, comma separated, previous names
+	// of the migration. It is written by Rename and Renumber so that databases where the
+	// migration has been applied under an old name can catch up.
+	RenamedFrom string
 }
 
 // DefaultMarshalOptions holds default marshal options for the migration used when
 // writing or reading migration files to the filesystem.
 var DefaultMarshalOptions = MarshalOptions{
-	UpStart:   "-- +migrataur up",
-	UpEnd:     "-- -migrataur up",
-	DownStart: "-- +migrataur down",
-	DownEnd:   "-- -migrataur down",
+	UpStart:      "-- +migrataur up",
+	UpEnd:        "-- -migrataur up",
+	DownStart:    "-- +migrataur down",
+	DownEnd:      "-- -migrataur down",
+	DependsOn:    "-- +migrataur depends-on:",
+	Replaces:     "-- +migrataur replaces:",
+	Irreversible: "-- +migrataur irreversible",
+	Tags:         "-- +migrataur tags:",
+	RenamedFrom:  "-- +migrataur renamed-from:",
 }
 
 var emptyMarshalOptions = MarshalOptions{}
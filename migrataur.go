@@ -5,173 +5,321 @@ package migrataur
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 )
 
-// Those ones are used to define migration's directions
-type dir int
+// Direction represents the direction in which migrations are run.
+type Direction int
 
 const (
-	// dirUp when applying migrations
-	dirUp dir = iota
-	// dirDown when rolling them back
-	dirDown
+	// Up when applying migrations
+	Up Direction = iota
+	// Down when rolling them back
+	Down
 )
 
+func (d Direction) String() string {
+	if d == Down {
+		return "down"
+	}
+
+	return "up"
+}
+
 // Migrataur represents an instance configurated for a particular use.
 // This is the main object you will use.
 type Migrataur struct {
 	options Options
 	adapter Adapter
+	set     string
+	root    *Migrataur
+	// sets is only populated on the root instance and holds every set, including the
+	// root itself as the DefaultSetName one
+	sets []*Migrataur
+	// dependsOn holds names of sets which must be migrated before this one
+	dependsOn []string
 }
 
 // New instantiates a new Migrataur instance for the given options
 func New(adapter Adapter, opts Options) *Migrataur {
-	return &Migrataur{
+	instance := &Migrataur{
 		adapter: adapter,
 		options: opts.ExtendWith(DefaultOptions),
+		set:     DefaultSetName,
 	}
+
+	instance.root = instance
+	instance.sets = []*Migrataur{instance}
+
+	return instance
 }
 
 // Init writes the initial migration provided by the adapter to create the needed
 // migrations table, you should call it at the start of your project.
 func (m *Migrataur) Init() (*Migration, error) {
-	m.Printf("Initializing migrataur")
+	m.step("Initializing migrataur")
+
+	migrationName, err := m.generateMigrationName(m.options.InitialMigrationName)
+
+	if err != nil {
+		return nil, m.fail(err)
+	}
 
-	fullPath := m.generateMigrationFullpath(m.options.InitialMigrationName)
 	up, down := m.adapter.GetInitialMigration()
 
 	initialMigration := &Migration{
-		Name: filepath.Base(fullPath),
+		Name: migrationName,
 		up:   up,
 		down: down,
 	}
 
-	if err := initialMigration.writeTo(fullPath, m.options.MarshalOptions); err != nil {
-		return nil, err
+	if err := m.writeNewMigration(initialMigration); err != nil {
+		return nil, m.fail(err)
 	}
 
-	m.Printf("\t%s created!", initialMigration.Name)
+	m.migrationEvent(EventMigrationCreated, initialMigration, "migration created")
 
 	return initialMigration, nil
 }
 
 // New creates a new migration in the configured folder and returns the
-// instance of the migration attached to the newly created file
-func (m *Migrataur) New(name string) (*Migration, error) {
-	m.Printf("Creating %s", name)
+// instance of the migration attached to the newly created file. Its sections are
+// empty unless populated with options such as WithContent or FromTemplate.
+func (m *Migrataur) New(name string, opts ...NewOption) (*Migration, error) {
+	m.step("Creating %s", name)
 
-	fullPath := m.generateMigrationFullpath(name)
-	migration := &Migration{Name: filepath.Base(fullPath)}
+	migrationName, err := m.generateMigrationName(name)
 
-	if err := migration.writeTo(fullPath, m.options.MarshalOptions); err != nil {
-		return nil, err
+	if err != nil {
+		return nil, m.fail(err)
 	}
 
-	m.Printf("\t%s created!", migration.Name)
+	migration := &Migration{Name: migrationName}
+
+	for _, opt := range opts {
+		if err := opt(m, migration); err != nil {
+			return nil, m.fail(err)
+		}
+	}
+
+	if err := m.writeNewMigration(migration); err != nil {
+		return nil, m.fail(err)
+	}
+
+	m.migrationEvent(EventMigrationCreated, migration, "migration created")
 
 	return migration, nil
 }
 
-// Remove one or many migrations given a name or a range. It will
+// Remove one or many migrations given a name or a selector expression (see Selector). It will
 // rollbacks them and delete needed files.
-func (m *Migrataur) Remove(rangeOrName string) ([]*Migration, error) {
-	m.Printf("Removing %s", rangeOrName)
+func (m *Migrataur) Remove(rangeOrName string, opts ...RunOption) ([]*Migration, error) {
+	m.step("Removing %s", rangeOrName)
 
-	start, end := getMigrationRange(rangeOrName)
+	all, err := m.getAllMigrations(Down)
+
+	if err != nil {
+		return nil, err
+	}
 
-	migrations, err := m.getAllMigrationsForRange(start, end, dirDown)
+	migrations, err := m.selectMigrations(all, rangeOrName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	m.Printf("Rollbacking applied migrations")
+	if err = checkDependents(all, migrations); err != nil {
+		return nil, m.fail(err)
+	}
+
+	m.step("Rollbacking applied migrations")
 
-	if _, err = m.apply(migrations, dirDown); err != nil {
+	if _, err = m.apply(migrations, Down, buildRunOptions(opts)); err != nil {
 		return nil, err
 	}
 
-	m.Printf("Removing files")
+	m.step("Removing files")
 
 	for _, mig := range migrations {
 		if err = fsAdapter.Remove(m.getMigrationFullpath(mig.Name)); err != nil {
 			return nil, err
 		}
 
-		m.Printf("✓\t%s deleted!", mig.Name)
+		m.migrationEvent(EventMigrationDeleted, mig, "migration deleted")
 	}
 
 	return migrations, nil
 }
 
-// GetAll retrieve all migrations for the current instance. It will list applied and pending migrations
+// GetAll retrieve all migrations for the current instance. It will list applied and pending migrations.
+// Orphans, applied migrations whose file was not found, are listed at the end whatever the
+// Options.Orphans policy is, see Migration.IsOrphan.
 func (m *Migrataur) GetAll() ([]*Migration, error) {
-	m.Printf("Fetching migrations in:\n\t%s", m.options.Directory)
+	m.step("Fetching migrations in:\n\t%s", m.options.Directory)
+
+	migrations, orphans, err := m.getAllMigrationsAndOrphans(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m.options.Orphans != OrphansIgnore {
+		for _, orphan := range orphans {
+			m.warn("%s is in the history but was not found in the migrations directory", orphan.Name)
+		}
+	}
 
-	return m.getAllMigrations(dirUp)
+	return append(migrations, orphans...), nil
 }
 
 // Migrate migrates the database and returns an array of effectively applied migrations (it will
 // not contains those that were already applied.
-// rangeOrName can be the exact migration name or a range such as <migration>..<another migration name>
-func (m *Migrataur) Migrate(rangeOrName string) ([]*Migration, error) {
-	m.Printf("Applying %s", rangeOrName)
+// rangeOrName can be a migration name, a range such as <migration>..<another migration name>
+// or any expression understood by Selector, like "migration01,migration03" or "tag:seed".
+func (m *Migrataur) Migrate(rangeOrName string, opts ...RunOption) ([]*Migration, error) {
+	m.step("Applying %s", rangeOrName)
 
-	return m.applyRange(rangeOrName, dirUp)
+	return m.applyRange(rangeOrName, Up, buildRunOptions(opts))
 }
 
 // MigrateToLatest migrates the database to the latest version
-func (m *Migrataur) MigrateToLatest() ([]*Migration, error) {
-	m.Printf("Applying all pending migrations")
+func (m *Migrataur) MigrateToLatest(opts ...RunOption) ([]*Migration, error) {
+	m.step("Applying all pending migrations")
 
-	return m.applyAll(dirUp)
+	applied, err := m.applyAll(Up, buildRunOptions(opts))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, m.dumpSchemaIfNeeded()
 }
 
 // Rollback inverts migrations and return an array of effectively rollbacked migrations
-// (it will not contains those that were not applied).
-func (m *Migrataur) Rollback(rangeOrName string) ([]*Migration, error) {
-	m.Printf("Rollbacking %s", rangeOrName)
+// (it will not contains those that were not applied). See Migrate for rangeOrName.
+func (m *Migrataur) Rollback(rangeOrName string, opts ...RunOption) ([]*Migration, error) {
+	m.step("Rollbacking %s", rangeOrName)
 
-	return m.applyRange(rangeOrName, dirDown)
+	return m.applyRange(rangeOrName, Down, buildRunOptions(opts))
 }
 
-// Reset resets the database to its initial state
-func (m *Migrataur) Reset() ([]*Migration, error) {
-	m.Printf("Resetting database")
+// Reset resets the database to its initial state. It fails with ErrProtectedEnvironment
+// if the environment is protected, unless given AllowProtected.
+func (m *Migrataur) Reset(opts ...RunOption) ([]*Migration, error) {
+	m.step("Resetting database")
+
+	runOpts := buildRunOptions(opts)
+
+	if !runOpts.allowProtected && m.IsProtected() {
+		return nil, m.fail(ErrProtectedEnvironment)
+	}
 
-	return m.applyAll(dirDown)
+	return m.applyAll(Down, runOpts)
 }
 
-// Printf logs a message using the provided Logger if any
-func (m *Migrataur) Printf(format string, args ...interface{}) {
-	if m.options.Logger != nil {
-		m.options.Logger.Printf(format, args...)
+// Baseline marks every migration up to the given one (or all of them if upTo is empty)
+// as applied without executing them. Use it when adopting migrataur on an existing
+// database. The initial migration is the only one really executed, if needed, so that
+// the history exists.
+func (m *Migrataur) Baseline(upTo string) ([]*Migration, error) {
+	m.step("Baselining up to %s", upTo)
+
+	migrations, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(migrations) == 0 || !m.isInitialMigration(migrations[0]) {
+		return nil, m.fail(fmt.Errorf("\tCould not find the initial migration %s, did you call Init?", m.options.InitialMigrationName))
+	}
+
+	if upTo != "" {
+		target, err := m.selectRange(migrations, upTo, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i, mig := range migrations {
+			if mig == target[0] {
+				migrations = migrations[:i+1]
+				break
+			}
+		}
 	}
+
+	baselined := []*Migration{}
+
+	ok, err := m.applyOne(migrations[0], Up, runOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		baselined = append(baselined, migrations[0])
+	}
+
+	applied, err := m.apply(migrations[1:], Up, runOptions{fake: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(baselined, applied...), nil
 }
 
-func (m *Migrataur) applyAll(direction dir) ([]*Migration, error) {
+// isInitialMigration checks if the given migration is the one created by Init, which creates
+// the history and must be executed before anything can be recorded
+func (m *Migrataur) isInitialMigration(migration *Migration) bool {
+	return migration.IsInitial() && strings.Contains(migration.Name, m.options.InitialMigrationName)
+}
+
+// Printf logs a message using the provided EventHandler or Logger if any. Since it is used
+// to output results, it is never filtered out by Options.MinLevel.
+func (m *Migrataur) Printf(format string, args ...interface{}) {
+	m.handle(Event{Kind: EventStep, Level: LevelInfo, Message: fmt.Sprintf(format, args...)})
+}
+
+// Warnf emits an EventWarning, filtered out by Options.MinLevel like any other event. Use
+// it from extensions to report something which went wrong without failing the run.
+func (m *Migrataur) Warnf(format string, args ...interface{}) {
+	m.warn(format, args...)
+}
+
+func (m *Migrataur) applyAll(direction Direction, opts runOptions) ([]*Migration, error) {
 	migrations, err := m.getAllMigrations(direction)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return m.apply(migrations, direction)
+	return m.apply(migrations, direction, opts)
 }
 
-func (m *Migrataur) applyRange(rangeOrName string, direction dir) ([]*Migration, error) {
-	start, end := getMigrationRange(rangeOrName)
-	migrations, err := m.getAllMigrationsForRange(start, end, direction)
+func (m *Migrataur) applyRange(rangeOrName string, direction Direction, opts runOptions) ([]*Migration, error) {
+	all, err := m.getAllMigrations(direction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.selectMigrations(all, rangeOrName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return m.apply(migrations, direction)
+	if err = checkDependencies(all, migrations, direction); err != nil {
+		return nil, m.fail(err)
+	}
+
+	return m.apply(migrations, direction, opts)
 }
 
 // getAllFromFilesystem reads all migrations in the directory and instantiates them.
@@ -191,7 +339,8 @@ func (m *Migrataur) getAllFromFilesystem() ([]*Migration, error) {
 	}
 
 	for _, f := range files {
-		if f.IsDir() {
+		// The schema snapshot may live next to migrations but is not one of them
+		if f.IsDir() || filepath.Join(m.options.Directory, f.Name()) == m.options.SchemaFile {
 			continue
 		}
 
@@ -213,24 +362,158 @@ func (m *Migrataur) getAllFromFilesystem() ([]*Migration, error) {
 	return migrations, err
 }
 
-// sortMigrations sorts given migrations by their name.
-func sortMigrations(migrations []*Migration, direction dir) {
-	if direction == dirUp {
-		sort.Sort(byName(migrations))
-	} else {
-		sort.Sort(sort.Reverse(byName(migrations)))
+// sortMigrations sorts given migrations so that each one comes after its dependencies,
+// falling back to their name when they do not depend on each other. When rolling back,
+// the order is reversed.
+func sortMigrations(migrations []*Migration, direction Direction) error {
+	sort.Sort(byName(migrations))
+
+	index := indexMigrations(migrations)
+	names := make([]string, len(migrations))
+	dependencies := map[string][]string{}
+
+	for i, mig := range migrations {
+		names[i] = mig.Name
+
+		for _, dep := range mig.dependsOn {
+			// Keep unknown dependencies as is so the sort reports them
+			if resolved, ok := index[dep]; ok {
+				dep = resolved.Name
+			}
+
+			dependencies[mig.Name] = append(dependencies[mig.Name], dep)
+		}
+	}
+
+	sorted, err := sortByDependencies("migration", names, dependencies)
+
+	if err != nil {
+		return err
+	}
+
+	count := len(sorted)
+
+	for i, name := range sorted {
+		if direction == Up {
+			migrations[i] = index[name]
+		} else {
+			migrations[count-i-1] = index[name]
+		}
+	}
+
+	return nil
+}
+
+// indexMigrations builds a map to retrieve migrations by their name, with or without
+// their extension, as used when declaring dependencies.
+func indexMigrations(migrations []*Migration) map[string]*Migration {
+	index := map[string]*Migration{}
+
+	for _, mig := range migrations {
+		index[nameWithoutExtension(mig.Name)] = mig
+	}
+
+	// Full names are set afterwards so they always win
+	for _, mig := range migrations {
+		index[mig.Name] = mig
+	}
+
+	return index
+}
+
+// checkDependencies makes sure that running the selected migrations in the given
+// direction will not leave a migration applied while one of its dependencies is not.
+func checkDependencies(all, selected []*Migration, direction Direction) error {
+	index := indexMigrations(all)
+	inSelection := map[*Migration]bool{}
+
+	for _, mig := range selected {
+		inSelection[mig] = true
+	}
+
+	for _, mig := range all {
+		for _, depName := range mig.dependsOn {
+			dep := index[depName]
+
+			switch direction {
+			case Up:
+				if inSelection[mig] && !mig.HasBeenApplied() && !inSelection[dep] && !dep.HasBeenApplied() {
+					return fmt.Errorf("the migration %s depends on %s which has not been applied", mig.Name, dep.Name)
+				}
+			case Down:
+				if inSelection[dep] && dep.HasBeenApplied() && !inSelection[mig] && mig.HasBeenApplied() {
+					return fmt.Errorf("the migration %s is required by %s which is still applied", dep.Name, mig.Name)
+				}
+			}
+		}
 	}
+
+	return nil
 }
 
 // apply given migrations in the given direction
-func (m *Migrataur) apply(migrations []*Migration, direction dir) ([]*Migration, error) {
+func (m *Migrataur) apply(migrations []*Migration, direction Direction, opts runOptions) ([]*Migration, error) {
+	hooks := m.options.Hooks
+	startedAt := time.Now()
+
+	if hooks.BeforeRun != nil {
+		if err := hooks.BeforeRun(RunEvent{Direction: direction, Migrations: migrations}); err != nil {
+			m.emit(Event{Kind: EventFailure, Message: "run aborted", Direction: direction, Err: err})
+			return nil, err
+		}
+	}
+
+	appliedMigrations, err := m.applyEach(migrations, direction, opts)
+
+	if hooks.AfterRun != nil {
+		hooks.AfterRun(RunEvent{
+			Direction:  direction,
+			Migrations: appliedMigrations,
+			Duration:   time.Since(startedAt),
+			Err:        err,
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedMigrations, nil
+}
+
+// applyEach applies migrations one after the other and returns those effectively applied,
+// even when an error occurs.
+func (m *Migrataur) applyEach(migrations []*Migration, direction Direction, opts runOptions) ([]*Migration, error) {
 	appliedMigrations := []*Migration{}
 
+	if err := m.reconcileHistory(migrations); err != nil {
+		return appliedMigrations, m.fail(err)
+	}
+
+	for _, mig := range migrations {
+		if mig.IsPartiallyApplied() {
+			return appliedMigrations, m.fail(fmt.Errorf("only some of the migrations squashed into %s have been applied, the history could not be rewritten", mig.Name))
+		}
+	}
+
+	if opts.fake && len(migrations) > 0 {
+		m.warn("FAKE MODE: only the history will be updated, migrations commands will NOT be executed!")
+	}
+
+	// Refuse to start if an irreversible migration is on the way
+	if direction == Down && !opts.allowIrreversible {
+		for _, mig := range migrations {
+			if mig.HasBeenApplied() && mig.IsIrreversible() {
+				return appliedMigrations, m.fail(&IrreversibleError{Migration: mig})
+			}
+		}
+	}
+
 	for _, mig := range migrations {
-		ok, err := m.applyOne(mig, direction)
+		ok, err := m.applyOne(mig, direction, opts)
 
 		if err != nil {
-			return nil, err
+			return appliedMigrations, err
 		}
 
 		if ok {
@@ -239,110 +522,157 @@ func (m *Migrataur) apply(migrations []*Migration, direction dir) ([]*Migration,
 	}
 
 	if len(appliedMigrations) == 0 {
-		m.Printf("\tAll clear, nothing done!")
+		m.info("All clear, nothing done!")
 	}
 
 	return appliedMigrations, nil
 }
 
-// getAllMigrationsForRange retrieves all migrations concerned by a range
-func (m *Migrataur) getAllMigrationsForRange(start, end string, direction dir) ([]*Migration, error) {
+// selectRange extracts migrations between start and end from the given sorted migrations.
+// If end is empty, only the start one is selected.
+func (m *Migrataur) selectRange(migrations []*Migration, start, end string) ([]*Migration, error) {
 	if start == "" {
 		return []*Migration{}, nil
 	}
 
-	migrations, err := m.getAllMigrations(direction)
+	kind := nameTerm
 
-	if err != nil {
-		return nil, err
+	if end != "" {
+		kind = rangeTerm
 	}
 
-	idxStart, idxEnd := -1, -1
+	selector := &Selector{
+		terms:     []selectorTerm{{kind: kind, start: start, end: end}},
+		exactOnly: m.options.ExactNames,
+	}
 
-	for i, mig := range migrations {
-		if idxStart == -1 {
-			if strings.Contains(mig.Name, start) {
-				idxStart = i
-
-				// Break early if no end migration has been set or if the end is the same
-				if end == "" || start == end {
-					idxEnd = idxStart + 1
-					break
-				}
-			}
-		} else {
-			// If we reach the end, break
-			if strings.Contains(mig.Name, end) {
-				idxEnd = i + 1
-				break
-			}
-		}
+	selected, err := selector.Select(migrations)
+
+	if err != nil {
+		return nil, m.fail(err)
 	}
 
-	if idxStart == -1 {
-		err := fmt.Errorf("\tCould not find the lower bound %s", start)
-		m.Printf(err.Error())
+	return selected, nil
+}
+
+// getAllMigrations retrieves all migrations from the filesystem, and from the
+// configurated adapter. It will mark them as applied if they are present in the
+// adapter.
+func (m *Migrataur) getAllMigrations(direction Direction) ([]*Migration, error) {
+	migrations, orphans, err := m.getAllMigrationsAndOrphans(direction)
+
+	if err != nil {
 		return nil, err
 	}
 
-	if idxEnd == -1 {
-		err := fmt.Errorf("\tCould not find the upper bound %s", end)
-		m.Printf(err.Error())
+	if err = m.handleOrphans(orphans); err != nil {
 		return nil, err
 	}
 
-	return migrations[idxStart:idxEnd], nil
+	return migrations, nil
 }
 
-// getAllMigrations retrieves all migrations from the filesystem, and from the
-// configurated adapter. It will mark them as applied if they are present in the
-// adapter.
-func (m *Migrataur) getAllMigrations(direction dir) ([]*Migration, error) {
+// getAllMigrationsAndOrphans does the same as getAllMigrations but also returns applied
+// migrations of the history which were not found in the migrations directory.
+func (m *Migrataur) getAllMigrationsAndOrphans(direction Direction) ([]*Migration, []*Migration, error) {
 
 	fileSystemMigrations, err := m.getAllFromFilesystem()
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	adapterMigrations, err := m.adapter.GetAll()
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	orphans := []*Migration{}
+
 	// Constructs the migrations map to easily update them with adapter ones
 	migrationsMap := map[string]*Migration{}
 	migrationsCount := len(fileSystemMigrations)
 
+	// Squashed and renamed migrations may still be in the history of databases where they
+	// were applied under their previous names
+	replacedBy := map[string]*Migration{}
+
 	for _, m := range fileSystemMigrations {
 		migrationsMap[m.Name] = m
+
+		for _, name := range m.replaces {
+			replacedBy[name] = m
+		}
+
+		for _, name := range m.renamedFrom {
+			replacedBy[name] = m
+		}
 	}
 
 	for _, mig := range adapterMigrations {
 		fsMigration, ok := migrationsMap[mig.Name]
 
 		if !ok {
-			return nil, fmt.Errorf("the migration %s was not found in the migrations directory", mig.Name)
+			if squashed, replaced := replacedBy[mig.Name]; replaced {
+				squashed.staleRows = append(squashed.staleRows, mig)
+				continue
+			}
+
+			mig.orphan = true
+			orphans = append(orphans, mig)
+			continue
 		}
 
-		fsMigration.hasBeenAppliedAt(*mig.AppliedAt)
+		fsMigration.recorded = true
+		fsMigration.hasBeenAppliedLike(mig)
 	}
 
-	sortMigrations(fileSystemMigrations, direction)
+	for _, mig := range fileSystemMigrations {
+		resolveStaleRows(mig)
+	}
+
+	if err = sortMigrations(fileSystemMigrations, direction); err != nil {
+		return nil, nil, err
+	}
 
 	// Find the initial migration and marks it. This is used primarily by adapters to
 	// perform specific behaviors
 	if migrationsCount > 0 {
 		switch direction {
-		case dirUp:
+		case Up:
 			fileSystemMigrations[0].markAsInitial()
-		case dirDown:
+		case Down:
 			fileSystemMigrations[migrationsCount-1].markAsInitial()
 		}
 	}
 
-	return fileSystemMigrations, nil
+	return fileSystemMigrations, orphans, nil
+}
+
+// checkDependents makes sure no migration outside of the selected ones depends on them,
+// which would leave a dangling dependency once they are removed.
+func checkDependents(all, selected []*Migration) error {
+	index := indexMigrations(all)
+	inSelection := map[*Migration]bool{}
+
+	for _, mig := range selected {
+		inSelection[mig] = true
+	}
+
+	for _, mig := range all {
+		if inSelection[mig] {
+			continue
+		}
+
+		for _, depName := range mig.dependsOn {
+			if dep := index[depName]; inSelection[dep] {
+				return fmt.Errorf("the migration %s is required by %s", dep.Name, mig.Name)
+			}
+		}
+	}
+
+	return nil
 }
 
 func getMigrationRange(rangeStr string) (first, last string) {
@@ -356,51 +686,141 @@ func getMigrationRange(rangeStr string) (first, last string) {
 }
 
 // applyOne runs a single migration and returns if it has been applied. If the migration
-// did not run because that was not needed, it will returns false.
-func (m *Migrataur) applyOne(migration *Migration, direction dir) (bool, error) {
+// did not run because that was not needed or because a hook skipped it, it will returns false.
+func (m *Migrataur) applyOne(migration *Migration, direction Direction, opts runOptions) (bool, error) {
 
 	// Do not execute commands if already applied or not applied at all when rolling back
-	if (migration.HasBeenApplied() && direction == dirUp) || (!migration.HasBeenApplied() && direction == dirDown) {
+	if (migration.HasBeenApplied() && direction == Up) || (!migration.HasBeenApplied() && direction == Down) {
 		return false, nil
 	}
 
+	hooks := m.options.Hooks
+	event := HookEvent{Migration: migration, Direction: direction}
+
+	if hooks.BeforeMigration != nil {
+		if err := hooks.BeforeMigration(event); err == ErrSkipMigration {
+			m.migrationEvent(EventMigrationSkipped, migration, "migration skipped by a hook")
+			return false, nil
+		} else if err != nil {
+			return false, m.migrationFailed(event, err)
+		}
+	}
+
+	startedAt := time.Now()
 	command := migration.up
 
-	if direction == dirDown {
+	if direction == Down {
 		command = migration.down
 	}
 
-	if err := m.adapter.Exec(command); err != nil {
-		m.Printf("✗\t%s: %s", migration.Name, err)
-		return false, err
+	// The initial migration creates the history so nothing could be recorded without it
+	fake := opts.fake && !(direction == Up && m.isInitialMigration(migration))
+
+	// Irreversible migrations rolled back on purpose may not have anything to execute
+	if !fake && strings.TrimSpace(command) != "" {
+		m.emit(Event{Kind: EventCommand, Migration: migration.Name, Direction: direction, Message: command})
+
+		if err := m.adapter.Exec(command); err != nil {
+			event.Duration = time.Since(startedAt)
+			return false, m.migrationFailed(event, err)
+		}
 	}
 
-	if direction == dirUp {
+	event.Duration = time.Since(startedAt)
+
+	if direction == Up {
 		migration.hasBeenAppliedAt(time.Now().UTC())
+		migration.Duration = event.Duration
+		migration.AppliedBy = currentOperator()
+		migration.Version = Version
+		migration.RunID = opts.runID
+		migration.Fake = fake
 
 		if err := m.adapter.MigrationApplied(migration); err != nil {
-			m.Printf("✗\t%s: %s", migration.Name, err)
-			return false, err
+			return false, m.migrationFailed(event, err)
 		}
 
 	} else {
 		migration.hasBeenRolledBack()
 
 		if err := m.adapter.MigrationRollbacked(migration); err != nil {
-			m.Printf("✗\t%s: %s", migration.Name, err)
-			return false, err
+			return false, m.migrationFailed(event, err)
 		}
 	}
 
-	m.Printf("✓\t%s", migration.Name)
+	message := "migration applied"
+
+	if direction == Down {
+		message = "migration rolled back"
+	}
+
+	m.emit(Event{
+		Kind:      EventMigrationApplied,
+		Message:   message,
+		Migration: migration.Name,
+		Direction: direction,
+		Duration:  event.Duration,
+		Fake:      fake,
+	})
+
+	if hooks.AfterMigration != nil {
+		hooks.AfterMigration(event)
+	}
 
 	return true, nil
 }
 
-func (m *Migrataur) generateMigrationFullpath(name string) string {
-	return m.getMigrationFullpath(fmt.Sprintf("%s_%s%s", m.options.SequenceGenerator(), name, m.options.Extension))
+// migrationFailed logs the error, notifies the OnError hook and returns the error
+func (m *Migrataur) migrationFailed(event HookEvent, err error) error {
+	m.emit(Event{
+		Kind:      EventFailure,
+		Message:   "migration failed",
+		Migration: event.Migration.Name,
+		Direction: event.Direction,
+		Duration:  event.Duration,
+		Err:       err,
+	})
+
+	if m.options.Hooks.OnError != nil {
+		event.Err = err
+		m.options.Hooks.OnError(event)
+	}
+
+	return err
+}
+
+// generateMigrationName prefixes the given name with a new sequence and appends the extension
+func (m *Migrataur) generateMigrationName(name string) (string, error) {
+	sequence := ""
+
+	if m.options.DirectorySequenceGenerator != nil {
+		var err error
+
+		if sequence, err = m.options.DirectorySequenceGenerator(m.options.Directory); err != nil {
+			return "", err
+		}
+	} else {
+		sequence = m.options.SequenceGenerator()
+	}
+
+	return fmt.Sprintf("%s_%s%s", sequence, name, m.options.Extension), nil
 }
 
 func (m *Migrataur) getMigrationFullpath(name string) string {
 	return filepath.Join(m.options.Directory, name)
 }
+
+// currentOperator retrieves who is applying migrations as user@host, recorded in the history
+func currentOperator() string {
+	name := os.Getenv("USER")
+
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return name + "@" + host
+	}
+
+	return name
+}
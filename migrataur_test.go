@@ -1,8 +1,10 @@
 package migrataur
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetRangeStr(t *testing.T) {
@@ -27,7 +29,7 @@ func TestGetRangeStr(t *testing.T) {
 		equals("migration07", last)
 }
 
-func TestGetAllMigrationsForRange(t *testing.T) {
+func TestMigrataurSelectMigrations(t *testing.T) {
 
 	mockFSAdapter.hasFiles(
 		mockFileInfo{name: "migration01.sql"},
@@ -39,50 +41,64 @@ func TestGetAllMigrationsForRange(t *testing.T) {
 
 	assert := assert(t)
 	instance := New(&mockAdapter{}, DefaultOptions)
+	selectMigrations := func(expr string, direction Direction) ([]*Migration, error) {
+		all, err := instance.getAllMigrations(direction)
 
-	migrations, err := instance.getAllMigrationsForRange("", "", dirUp)
+		assert.nil(err)
+
+		return instance.selectMigrations(all, expr)
+	}
+
+	migrations, err := selectMigrations("", Up)
 
 	assert.
 		nil(err).
 		equals(0, len(migrations))
 
-	_, err = instance.getAllMigrationsForRange("doesnotexists", "", dirUp)
+	_, err = selectMigrations("doesnotexists", Up)
 
 	assert.
 		notNil(err)
 
-	_, err = instance.getAllMigrationsForRange("migration01", "doesnotexists", dirUp)
+	_, err = selectMigrations("migration01..doesnotexists", Up)
 
 	assert.
 		notNil(err)
 
-	migrations, err = instance.getAllMigrationsForRange("migration01", "", dirUp)
+	migrations, err = selectMigrations("migration01", Up)
 
 	assert.
 		nil(err).
 		equals(1, len(migrations)).
 		applied(migrations, "migration01")
 
-	migrations, err = instance.getAllMigrationsForRange("migration03", "migration05", dirUp)
+	migrations, err = selectMigrations("migration03..migration05", Up)
 
 	assert.
 		nil(err).
 		equals(3, len(migrations)).
 		applied(migrations, "migration03", "migration04", "migration05")
 
-	migrations, err = instance.getAllMigrationsForRange("migration05", "", dirDown)
+	migrations, err = selectMigrations("migration05", Down)
 
 	assert.
 		nil(err).
 		equals(1, len(migrations)).
 		applied(migrations, "migration05")
 
-	migrations, err = instance.getAllMigrationsForRange("migration05", "migration02", dirDown)
+	migrations, err = selectMigrations("migration05..migration02", Down)
 
 	assert.
 		nil(err).
 		equals(4, len(migrations)).
 		applied(migrations, "migration05", "migration04", "migration03", "migration02")
+
+	migrations, err = selectMigrations("migration01,migration03..migration04", Up)
+
+	assert.
+		nil(err).
+		equals(3, len(migrations)).
+		applied(migrations, "migration01", "migration03", "migration04")
 }
 
 func TestMigrataurInit(t *testing.T) {
@@ -114,6 +130,43 @@ func TestMigrataurNew(t *testing.T) {
 		exists(migration.Name)
 }
 
+func TestMigrataurNewWithContent(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "create-table.sql", content: `-- +migrataur up
+create table {{.Name}}();
+-- -migrataur up
+
+-- +migrataur down
+drop table {{.Name}};
+-- -migrataur down`},
+		mockFileInfo{name: "broken.sql", content: "create table {{.Name"},
+	)
+
+	assert := assert(t)
+	// Migrations are created within the same second
+	opts := DefaultOptions
+	opts.SequenceGenerator = GetCurrentTimeFormattedMicro
+	instance := New(&mockAdapter{}, opts)
+	migration, err := instance.New("movies", FromTemplate("create-table"))
+
+	assert.
+		nil(err).
+		equals(filepath.Join(instance.options.Directory, migration.Name), instance.MigrationPath(migration)).
+		contains("create table movies();", mockFSAdapter.content(migration.Name)).
+		contains("drop table movies;", mockFSAdapter.content(migration.Name))
+
+	migration, err = instance.New("actors", WithContent([]byte("create table actors();\n")))
+
+	assert.
+		nil(err).
+		equals("create table actors();", migration.up).
+		equals("", migration.down)
+
+	_, err = instance.New("broken", FromTemplate("broken"))
+
+	assert.notNil(err)
+}
+
 func TestMigrataurRemove(t *testing.T) {
 	mockFSAdapter.hasFiles(
 		mockFileInfo{name: "migration01.sql"},
@@ -345,11 +398,11 @@ func TestMigrationsSorting(t *testing.T) {
 		{Name: "migration01"},
 	}
 
-	sortMigrations(migrations, dirUp)
+	sortMigrations(migrations, Up)
 
 	assert.applied(migrations, "migration01", "migration02", "migration03", "migration04")
 
-	sortMigrations(migrations, dirDown)
+	sortMigrations(migrations, Down)
 
 	assert.applied(migrations, "migration04", "migration03", "migration02", "migration01")
 }
@@ -363,3 +416,326 @@ func TestWithLoggerNilShouldNotPanic(t *testing.T) {
 
 	assert(t).nil(instance.options.Logger)
 }
+
+func TestMigrationsSortingWithDependencies(t *testing.T) {
+	assert := assert(t)
+
+	migrations := []*Migration{
+		{Name: "migration03.sql"},
+		{Name: "migration01.sql", dependsOn: []string{"migration04"}},
+		{Name: "migration02.sql"},
+		{Name: "migration04.sql", dependsOn: []string{"migration03.sql"}},
+	}
+
+	assert.
+		nil(sortMigrations(migrations, Up)).
+		applied(migrations, "migration02", "migration03", "migration04", "migration01").
+		nil(sortMigrations(migrations, Down)).
+		applied(migrations, "migration01", "migration04", "migration03", "migration02")
+
+	migrations = []*Migration{
+		{Name: "migration01.sql", dependsOn: []string{"migration02"}},
+		{Name: "migration02.sql", dependsOn: []string{"migration01"}},
+	}
+
+	err := sortMigrations(migrations, Up)
+
+	assert.
+		notNil(err).
+		contains("cycle", err.Error())
+
+	migrations = []*Migration{
+		{Name: "migration01.sql", dependsOn: []string{"doesnotexists"}},
+	}
+
+	err = sortMigrations(migrations, Up)
+
+	assert.
+		notNil(err).
+		contains("doesnotexists", err.Error())
+}
+
+func TestMigrataurWithDependencies(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql", content: DefaultMarshalOptions.DependsOn + " migration03\n" + mockMigrationContent},
+		mockFileInfo{name: "migration03.sql"},
+		mockFileInfo{name: "migration04.sql"},
+	)
+
+	assert := assert(t)
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	_, err := instance.Migrate("migration02")
+
+	assert.notNil(err)
+
+	applied, err := instance.Migrate("migration01..migration02")
+
+	assert.
+		nil(err).
+		applied(applied, "migration01", "migration03", "migration02")
+
+	_, err = instance.Rollback("migration03")
+
+	assert.notNil(err)
+
+	_, err = instance.Remove("migration03")
+
+	assert.notNil(err)
+
+	applied, err = instance.Rollback("migration02..migration03")
+
+	assert.
+		nil(err).
+		applied(applied, "migration02", "migration03")
+}
+
+func TestMigrataurBaseline(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.Baseline("")
+
+	assert.notNil(err)
+
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration00_" + DefaultOptions.InitialMigrationName + ".sql", content: `-- +migrataur up
+create history
+-- -migrataur up`},
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+	)
+
+	applied, err := instance.Baseline("migration02")
+
+	assert.
+		nil(err).
+		applied(applied, DefaultOptions.InitialMigrationName, "migration01", "migration02").
+		equals(1, len(adapter.executed)).
+		equals("create history", adapter.executed[0]).
+		equals(3, len(adapter.appliedMigrations))
+
+	applied, err = instance.Baseline("")
+
+	assert.
+		nil(err).
+		applied(applied, "migration03").
+		equals(1, len(adapter.executed))
+}
+
+func TestMigrataurFakeMode(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	applied, err := instance.Migrate("migration01..migration02", Fake())
+
+	assert.
+		nil(err).
+		applied(applied, "migration01", "migration02").
+		true(applied[0].HasBeenApplied()).
+		true(applied[0].Fake).
+		contains("(fake)", applied[0].String()).
+		contains("FAKE", applied[0].Details()).
+		equals(0, len(adapter.executed)).
+		equals(2, len(adapter.appliedMigrations))
+
+	rollbacked, err := instance.Rollback("migration02", Fake())
+
+	assert.
+		nil(err).
+		applied(rollbacked, "migration02").
+		false(rollbacked[0].HasBeenApplied()).
+		equals(0, len(adapter.executed)).
+		equals(1, len(adapter.appliedMigrations))
+
+	applied, err = instance.MigrateToLatest()
+
+	assert.
+		nil(err).
+		applied(applied, "migration02").
+		false(applied[0].Fake).
+		equals(1, len(adapter.executed))
+}
+
+func TestMigrataurFakeModeOnFreshDatabase(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration00_" + DefaultOptions.InitialMigrationName + ".sql", content: `-- +migrataur up
+create history
+-- -migrataur up`},
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	adapter := &freshAdapter{mockAdapter: newMockAdapter()}
+	instance := New(adapter, DefaultOptions)
+
+	applied, err := instance.MigrateToLatest(Fake())
+
+	assert.
+		nil(err).
+		applied(applied, DefaultOptions.InitialMigrationName, "migration01", "migration02").
+		false(applied[0].Fake).
+		true(applied[1].Fake).
+		equals(1, len(adapter.executed)).
+		equals("create history", adapter.executed[0])
+}
+
+func TestMigrataurIrreversible(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql", content: DefaultMarshalOptions.Irreversible + "\n" + mockMigrationContent},
+		mockFileInfo{name: "migration03.sql", content: `-- +migrataur up
+create horses;
+-- -migrataur up
+-- +migrataur down
+-- -migrataur down`},
+		mockFileInfo{name: "migration04.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	applied, err := instance.Rollback("migration04")
+
+	assert.
+		nil(err).
+		applied(applied, "migration04")
+
+	_, err = instance.Rollback("migration03..migration02")
+
+	irreversibleErr, ok := err.(*IrreversibleError)
+
+	assert.
+		true(ok).
+		contains("migration03", irreversibleErr.Migration.Name).
+		equals(3, len(adapter.appliedMigrations))
+
+	_, err = instance.Reset()
+
+	assert.notNil(err)
+
+	_, err = instance.Remove("migration02")
+
+	assert.notNil(err)
+
+	executedCount := len(adapter.executed)
+	applied, err = instance.Rollback("migration03..migration02", AllowIrreversible())
+
+	assert.
+		nil(err).
+		applied(applied, "migration03", "migration02").
+		equals(1, len(adapter.appliedMigrations)).
+		// Only migration02 has a down section to execute
+		equals(executedCount+1, len(adapter.executed))
+}
+
+func TestMigrataurHistoryMetadata(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	applied, err := instance.MigrateToLatest(WithRunID("deploy-42"))
+
+	assert.
+		nil(err).
+		applied(applied, "migration01").
+		equals("deploy-42", applied[0].RunID).
+		equals(Version, applied[0].Version).
+		notEquals("", applied[0].AppliedBy).
+		contains("[run deploy-42]", applied[0].Details()).
+		contains(applied[0].AppliedBy, adapter.appliedMigrations[0].Details())
+
+	migrations, err := instance.GetAll()
+
+	assert.
+		nil(err).
+		equals("deploy-42", migrations[0].RunID)
+
+	rollbacked, err := instance.Rollback("migration01")
+
+	assert.
+		nil(err).
+		equals("", rollbacked[0].RunID).
+		equals("pending", rollbacked[0].Details()).
+		notNil(instance.UpgradeHistory())
+}
+
+func TestMigrataurRedo(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+	)
+
+	assert := assert(t)
+	adapter := &lockingAdapter{mockAdapter: newMockAdapter()}
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.Redo(1)
+
+	assert.nil(err)
+
+	_, err = instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	adapter.executed = nil
+	redone, err := instance.Redo(2)
+
+	assert.
+		nil(err).
+		applied(redone, "migration02", "migration03").
+		equals(4, len(adapter.executed)).
+		equals("-- down", adapter.executed[0]).
+		equals("-- up", adapter.executed[3]).
+		equals(3, len(adapter.appliedMigrations)).
+		equals(2, adapter.acquired).
+		false(adapter.held)
+
+	// migration02 has been applied last, after a late merge for example
+	later := time.Now().Add(time.Hour)
+	adapter.appliedMigrations[1].AppliedAt = &later
+	adapter.executed = nil
+	redone, err = instance.Redo(1)
+
+	assert.
+		nil(err).
+		applied(redone, "migration02").
+		equals(2, len(adapter.executed))
+
+	_, err = instance.Redo(0)
+
+	assert.notNil(err)
+
+	opts := DefaultOptions
+	opts.LockTimeout = time.Millisecond
+	adapter.held = true
+
+	_, err = New(adapter, opts).Redo(1)
+
+	assert.notNil(err)
+}
@@ -14,7 +14,29 @@ type Migration struct {
 	up        string
 	down      string
 	AppliedAt *time.Time
-	isInitial bool
+	// Duration, AppliedBy, Version, RunID and Fake are recorded when the migration is applied.
+	// Adapters with an older history may not have them for every migration.
+	Duration  time.Duration
+	AppliedBy string
+	Version   string
+	RunID     string
+	// Fake is true when the migration has only been recorded in the history, its commands
+	// were NOT executed. See the Fake run option.
+	Fake         bool
+	isInitial    bool
+	dependsOn    []string
+	replaces     []string
+	renamedFrom  []string
+	tags         []string
+	irreversible bool
+	orphan       bool
+	// staleRows are rows of the history standing for this migration under other names, such
+	// as migrations squashed into it. They are rewritten by reconcileHistory.
+	staleRows []*Migration
+	// recorded tells if the history has a row with the name of this migration
+	recorded bool
+	// partial is set when only some of the migrations squashed into this one have been applied
+	partial bool
 }
 
 // byName sort an array of migrations by their name, use it with sort.Sort and the like
@@ -24,11 +46,34 @@ func (m byName) Len() int           { return len(m) }
 func (m byName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
 func (m byName) Less(i, j int) bool { return m[i].Name < m[j].Name }
 
+// byAppliedAt sort an array of applied migrations by their application date, then by name
+type byAppliedAt []*Migration
+
+func (m byAppliedAt) Len() int      { return len(m) }
+func (m byAppliedAt) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m byAppliedAt) Less(i, j int) bool {
+	if !m[i].AppliedAt.Equal(*m[j].AppliedAt) {
+		return m[i].AppliedAt.Before(*m[j].AppliedAt)
+	}
+
+	return m[i].Name < m[j].Name
+}
+
 func (m *Migration) String() string {
 	ticked := " "
 
+	if m.orphan {
+		return fmt.Sprintf("[!]\t%s (not found in the migrations directory)", m.Name)
+	}
+
 	if m.HasBeenApplied() {
 		ticked = "✓"
+	} else if m.partial {
+		ticked = "~"
+	}
+
+	if m.Fake {
+		return fmt.Sprintf("[%s]\t%s (fake)", ticked, m.Name)
 	}
 
 	return fmt.Sprintf("[%s]\t%s", ticked, m.Name)
@@ -38,8 +83,58 @@ func (m *Migration) hasBeenAppliedAt(time time.Time) {
 	m.AppliedAt = &time
 }
 
+// hasBeenAppliedLike copies the history metadata of the given migration, retrieved from an adapter
+func (m *Migration) hasBeenAppliedLike(row *Migration) {
+	m.hasBeenAppliedAt(*row.AppliedAt)
+	m.Duration = row.Duration
+	m.AppliedBy = row.AppliedBy
+	m.Version = row.Version
+	m.RunID = row.RunID
+	m.Fake = row.Fake
+}
+
 func (m *Migration) hasBeenRolledBack() {
 	m.AppliedAt = nil
+	m.Duration = 0
+	m.AppliedBy = ""
+	m.Version = ""
+	m.RunID = ""
+	m.Fake = false
+}
+
+// Details retrieves a human readable summary of the history metadata of this migration.
+func (m *Migration) Details() string {
+	if m.partial {
+		return fmt.Sprintf("partially applied, %d of the %d migrations squashed into it", len(m.staleRows), len(m.replaces))
+	}
+
+	if !m.HasBeenApplied() {
+		return "pending"
+	}
+
+	details := fmt.Sprintf("applied at %s", m.AppliedAt.Format(time.RFC3339))
+
+	if m.AppliedBy != "" {
+		details += " by " + m.AppliedBy
+	}
+
+	if m.Duration > 0 {
+		details += fmt.Sprintf(" in %s", m.Duration)
+	}
+
+	if m.Version != "" {
+		details += fmt.Sprintf(" (migrataur %s)", m.Version)
+	}
+
+	if m.RunID != "" {
+		details += fmt.Sprintf(" [run %s]", m.RunID)
+	}
+
+	if m.Fake {
+		details += ", FAKE: commands were NOT executed"
+	}
+
+	return details
 }
 
 // HasBeenApplied checks if the migration has already been applied in the database.
@@ -47,6 +142,13 @@ func (m *Migration) HasBeenApplied() bool {
 	return m.AppliedAt != nil
 }
 
+// IsPartiallyApplied checks if only some of the migrations squashed into this one have been
+// applied in the database. It can not be applied nor rolled back until the database has
+// been brought back to a consistent state with the migrations as they were before the squash.
+func (m *Migration) IsPartiallyApplied() bool {
+	return m.partial
+}
+
 // markAsInitial marks this migration as the initial one. This is useful in adapters.
 func (m *Migration) markAsInitial() {
 	m.isInitial = true
@@ -58,9 +160,77 @@ func (m *Migration) IsInitial() bool {
 	return m.isInitial
 }
 
+// DependsOn retrieves names of migrations that must be applied before this one.
+func (m *Migration) DependsOn() []string {
+	return m.dependsOn
+}
+
+// Replaces retrieves names of migrations which have been squashed into this one.
+func (m *Migration) Replaces() []string {
+	return m.replaces
+}
+
+// RenamedFrom retrieves previous names of this migration.
+func (m *Migration) RenamedFrom() []string {
+	return m.renamedFrom
+}
+
+// Tags retrieves tags declared in the migration header.
+func (m *Migration) Tags() []string {
+	return m.tags
+}
+
+// HasTag checks if the migration has been tagged with the given tag.
+func (m *Migration) HasTag(tag string) bool {
+	for _, t := range m.tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsIrreversible checks if this migration can not be rolled back, either because it has been
+// explicitly marked as such or because its down section is empty.
+func (m *Migration) IsIrreversible() bool {
+	return m.irreversible || strings.TrimSpace(m.down) == ""
+}
+
+// IrreversibleError is returned when trying to rollback an irreversible migration.
+type IrreversibleError struct {
+	Migration *Migration
+}
+
+func (e *IrreversibleError) Error() string {
+	return fmt.Sprintf("the migration %s is irreversible", e.Migration.Name)
+}
+
 // marshal serializes this migration
 func (m *Migration) marshal(options MarshalOptions) (text []byte, err error) {
-	content := fmt.Sprintf(`%s
+	header := ""
+
+	if len(m.dependsOn) > 0 && options.DependsOn != "" {
+		header += fmt.Sprintf("%s %s\n", options.DependsOn, strings.Join(m.dependsOn, ", "))
+	}
+
+	if len(m.replaces) > 0 && options.Replaces != "" {
+		header += fmt.Sprintf("%s %s\n", options.Replaces, strings.Join(m.replaces, ", "))
+	}
+
+	if len(m.renamedFrom) > 0 && options.RenamedFrom != "" {
+		header += fmt.Sprintf("%s %s\n", options.RenamedFrom, strings.Join(m.renamedFrom, ", "))
+	}
+
+	if len(m.tags) > 0 && options.Tags != "" {
+		header += fmt.Sprintf("%s %s\n", options.Tags, strings.Join(m.tags, ", "))
+	}
+
+	if m.irreversible && options.Irreversible != "" {
+		header += options.Irreversible + "\n"
+	}
+
+	content := header + fmt.Sprintf(`%s
 %s
 %s
 
@@ -77,32 +247,114 @@ func (m *Migration) unmarshal(text []byte, options MarshalOptions) error {
 	lines := strings.Split(string(text), "\n")
 
 	upFrom, downFrom := 0, 0
+	// Directives are only read in the header, before any section starts
+	inHeader := true
 
 	for i := 0; i < len(lines); i++ {
 		switch lines[i] {
 		case options.UpStart:
 			upFrom = i
+			inHeader = false
 		case options.UpEnd:
 			m.up = strings.Join(lines[upFrom+1:i], "\n")
 		case options.DownStart:
 			downFrom = i
+			inHeader = false
 		case options.DownEnd:
 			m.down = strings.Join(lines[downFrom+1:i], "\n")
+		default:
+			if !inHeader {
+				continue
+			}
+
+			if options.DependsOn != "" && strings.HasPrefix(lines[i], options.DependsOn) {
+				m.dependsOn = parseNamesList(strings.TrimPrefix(lines[i], options.DependsOn))
+			} else if options.Replaces != "" && strings.HasPrefix(lines[i], options.Replaces) {
+				m.replaces = parseNamesList(strings.TrimPrefix(lines[i], options.Replaces))
+			} else if options.RenamedFrom != "" && strings.HasPrefix(lines[i], options.RenamedFrom) {
+				m.renamedFrom = parseNamesList(strings.TrimPrefix(lines[i], options.RenamedFrom))
+			} else if options.Tags != "" && strings.HasPrefix(lines[i], options.Tags) {
+				m.tags = parseNamesList(strings.TrimPrefix(lines[i], options.Tags))
+			} else if options.Irreversible != "" && strings.TrimSpace(lines[i]) == options.Irreversible {
+				m.irreversible = true
+			}
 		}
 	}
 
 	return nil
 }
 
+// setDirective rewrites the header line of the given directive in a serialized migration,
+// leaving every other line, comments included, untouched. The line is added before the
+// first section if missing and removed if names is empty.
+func setDirective(text []byte, options MarshalOptions, prefix string, names []string) []byte {
+	if prefix == "" {
+		return text
+	}
+
+	lines := strings.Split(string(text), "\n")
+	line := fmt.Sprintf("%s %s", prefix, strings.Join(names, ", "))
+	headerEnd := len(lines)
+
+	for i, l := range lines {
+		if l == options.UpStart || l == options.DownStart {
+			headerEnd = i
+			break
+		}
+
+		if !strings.HasPrefix(l, prefix) {
+			continue
+		}
+
+		if len(names) == 0 {
+			lines = append(lines[:i], lines[i+1:]...)
+		} else {
+			lines[i] = line
+		}
+
+		return []byte(strings.Join(lines, "\n"))
+	}
+
+	if len(names) == 0 {
+		return text
+	}
+
+	lines = append(lines[:headerEnd], append([]string{line}, lines[headerEnd:]...)...)
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// parseNamesList parses a comma separated list of names, ignoring empty ones
+func parseNamesList(list string) []string {
+	names := []string{}
+
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
 // writeTo writes this migration to the filesystem using given MarshalOptions.
 func (m *Migration) writeTo(path string, options MarshalOptions) error {
+	return m.write(fsAdapter.Create, path, options)
+}
+
+// writeNewTo does the same as writeTo but fails if the file already exists.
+func (m *Migration) writeNewTo(path string, options MarshalOptions) error {
+	return m.write(fsAdapter.CreateExclusive, path, options)
+}
+
+func (m *Migration) write(create func(string) (file, error), path string, options MarshalOptions) error {
 
 	// Make sure the directory exists
 	if err := fsAdapter.MkdirAll(filepath.Dir(path), os.ModeDir); err != nil {
 		return err
 	}
 
-	file, err := fsAdapter.Create(path)
+	file, err := create(path)
 
 	if err != nil {
 		return err
@@ -69,3 +69,60 @@ func TestMigrationToString(t *testing.T) {
 		false(notAppliedMigration.HasBeenApplied()).
 		equals(fmt.Sprintf("[ ]\t%s", notAppliedMigration.Name), notAppliedMigration.String())
 }
+
+func TestMigrationDependenciesMarshaling(t *testing.T) {
+	assert := assert(t)
+
+	migration := Migration{
+		Name:      "migration03",
+		up:        "alter table horses add column age int;",
+		down:      "alter table horses drop column age;",
+		dependsOn: []string{"migration01", "migration02.sql"},
+	}
+
+	data, err := migration.marshal(DefaultMarshalOptions)
+
+	assert.
+		nil(err).
+		contains(DefaultMarshalOptions.DependsOn+" migration01, migration02.sql\n", string(data))
+
+	unmarshaled := Migration{Name: "migration03"}
+
+	assert.
+		nil(unmarshaled.unmarshal(data, DefaultMarshalOptions)).
+		equals(migration.up, unmarshaled.up).
+		equals(migration.down, unmarshaled.down).
+		equals(2, len(unmarshaled.DependsOn())).
+		equals("migration01", unmarshaled.DependsOn()[0]).
+		equals("migration02.sql", unmarshaled.DependsOn()[1])
+
+	// Directives are not read inside sections
+	unmarshaled = Migration{Name: "migration04"}
+	unmarshaled.unmarshal([]byte(`-- +migrataur up
+-- +migrataur depends-on: migration01
+-- -migrataur up`), DefaultMarshalOptions)
+
+	assert.equals(0, len(unmarshaled.DependsOn()))
+}
+
+func TestMigrationIrreversible(t *testing.T) {
+	assert := assert(t)
+
+	migration := Migration{Name: "migration01", up: "create table horses;", down: "drop table horses;"}
+
+	assert.false(migration.IsIrreversible())
+
+	migration.down = "  \n"
+
+	assert.true(migration.IsIrreversible())
+
+	migration = Migration{Name: "migration02", up: "create table riders;", down: "drop table riders;", irreversible: true}
+	data, _ := migration.marshal(DefaultMarshalOptions)
+	unmarshaled := Migration{Name: "migration02"}
+
+	assert.
+		contains(DefaultMarshalOptions.Irreversible+"\n", string(data)).
+		nil(unmarshaled.unmarshal(data, DefaultMarshalOptions)).
+		true(unmarshaled.IsIrreversible()).
+		equals(migration.down, unmarshaled.down)
+}
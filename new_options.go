@@ -0,0 +1,72 @@
+package migrataur
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplatesDirectory is the directory, inside the migrations one, holding skeletons which
+// can be used by New with FromTemplate.
+const TemplatesDirectory = "templates"
+
+// NewOption customizes a migration created by New.
+type NewOption func(m *Migrataur, migration *Migration) error
+
+// WithContent populates the migration from the given content. If it contains up and down
+// sections, as a migration file does, they are used as is. Otherwise the whole content is
+// used as the up section.
+func WithContent(content []byte) NewOption {
+	return func(m *Migrataur, migration *Migration) error {
+		return migration.populateFrom(content, m.options.MarshalOptions)
+	}
+}
+
+// FromTemplate populates the migration with the named skeleton stored in the templates
+// directory, such as "create-table" for templates/create-table.sql. Templates are parsed with
+// text/template and given the migration name without its sequence as .Name.
+func FromTemplate(name string) NewOption {
+	return func(m *Migrataur, migration *Migration) error {
+		path := filepath.Join(m.options.Directory, TemplatesDirectory, name+m.options.Extension)
+		data, err := fsAdapter.ReadFile(path)
+
+		if err != nil {
+			return fmt.Errorf("the template %s could not be read: %s", name, err)
+		}
+
+		tmpl, err := template.New(name).Parse(string(data))
+
+		if err != nil {
+			return fmt.Errorf("the template %s is not valid: %s", name, err)
+		}
+
+		var buf bytes.Buffer
+
+		if err = tmpl.Execute(&buf, struct{ Name string }{nameWithoutSequence(migration.Name)}); err != nil {
+			return fmt.Errorf("the template %s could not be executed: %s", name, err)
+		}
+
+		return migration.populateFrom(buf.Bytes(), m.options.MarshalOptions)
+	}
+}
+
+// populateFrom reads sections from the given content, which is used as the up section if
+// it has none
+func (m *Migration) populateFrom(content []byte, options MarshalOptions) error {
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == options.UpStart || line == options.DownStart {
+			return m.unmarshal(content, options)
+		}
+	}
+
+	m.up = strings.TrimSpace(string(content))
+
+	return nil
+}
+
+// MigrationPath retrieves the path of the file of the given migration.
+func (m *Migrataur) MigrationPath(migration *Migration) string {
+	return m.getMigrationFullpath(migration.Name)
+}
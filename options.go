@@ -30,7 +30,38 @@ type Options struct {
 	Extension            string
 	InitialMigrationName string
 	SequenceGenerator    func() string
-	MarshalOptions       MarshalOptions
+	// DirectorySequenceGenerator, if set, is used instead of the SequenceGenerator. It is
+	// given the migrations directory at the time a migration is created, see IncrementalSequence.
+	DirectorySequenceGenerator func(directory string) (string, error)
+	MarshalOptions             MarshalOptions
+	// SchemaFile, if set, is the path of the schema snapshot written after MigrateToLatest
+	// and read by LoadSchema. The adapter must implement SchemaDumper. Just like the
+	// Logger, it is never taken from the extended Options.
+	SchemaFile string
+	// Hooks are called while running migrations. Just like the Logger, they are never
+	// taken from the extended Options.
+	Hooks Hooks
+	// EventHandler, if set, receives every event as a structured entry and the Logger is
+	// not used at all. It is never taken from the extended Options.
+	EventHandler EventHandler
+	// ExactNames disables substring matching of migration names given to Migrate, Rollback
+	// and the like, which is safer in production. See Selector.
+	ExactNames bool
+	// Orphans tells what to do with migrations found in the history but not in the directory.
+	// By default, nothing will be run until they are removed with PruneHistory.
+	Orphans OrphanPolicy
+	// Protected refuses to Reset the database unless AllowProtected is given. The environment
+	// is also protected if the MIGRATAUR_PROTECTED variable is set to a true value.
+	Protected bool
+	// MinLevel drops events below the given level: LevelWarn to only output warnings and
+	// failures, LevelDebug to also output executed commands. Defaults to LevelInfo.
+	MinLevel Level
+	// OwnMinLevel makes a set registered with AddSet use its MinLevel instead of the one
+	// of its parent instance.
+	OwnMinLevel bool
+	// LockTimeout is the maximum time waited for the adapter lock by operations holding it,
+	// such as Redo. Defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
 }
 
 // DefaultOptions represents the default migrataur options
@@ -41,6 +72,7 @@ var DefaultOptions = Options{
 	InitialMigrationName: "createMigrationHistory",
 	SequenceGenerator:    GetCurrentTimeFormatted,
 	MarshalOptions:       DefaultMarshalOptions,
+	LockTimeout:          DefaultLockTimeout,
 }
 
 // ExtendWith extends self with the given Options. It means that if a field is not
@@ -62,6 +94,12 @@ func (opts Options) ExtendWith(other Options) Options {
 
 	result.Directory = absPath
 
+	if result.SchemaFile != "" {
+		if result.SchemaFile, err = filepath.Abs(result.SchemaFile); err != nil {
+			panic(err)
+		}
+	}
+
 	if result.Extension == "" {
 		result.Extension = other.Extension
 	}
@@ -75,6 +113,11 @@ func (opts Options) ExtendWith(other Options) Options {
 		result.Extension = "." + result.Extension
 	}
 
+	// A generator explicitly given takes precedence over the extended one, whatever its kind
+	if result.SequenceGenerator == nil && result.DirectorySequenceGenerator == nil {
+		result.DirectorySequenceGenerator = other.DirectorySequenceGenerator
+	}
+
 	if result.SequenceGenerator == nil {
 		result.SequenceGenerator = other.SequenceGenerator
 	}
@@ -83,6 +126,10 @@ func (opts Options) ExtendWith(other Options) Options {
 		result.MarshalOptions = other.MarshalOptions
 	}
 
+	if result.LockTimeout <= 0 {
+		result.LockTimeout = other.LockTimeout
+	}
+
 	return result
 }
 
@@ -0,0 +1,75 @@
+package migrataur
+
+import "fmt"
+
+// OrphanPolicy tells what to do with orphans: migrations found in the history but not in
+// the migrations directory, because their file has been deleted or renamed by hand.
+type OrphanPolicy int
+
+const (
+	// OrphansFail refuses to run anything while there are orphans
+	OrphansFail OrphanPolicy = iota
+	// OrphansWarn emits a warning for each orphan and carry on
+	OrphansWarn
+	// OrphansIgnore silently carry on
+	OrphansIgnore
+)
+
+// IsOrphan checks if the migration has been found in the history but not in the migrations
+// directory. Orphans are only returned by GetAll and GetOrphans.
+func (m *Migration) IsOrphan() bool {
+	return m.orphan
+}
+
+// GetOrphans retrieves migrations found in the history but not in the migrations directory.
+func (m *Migrataur) GetOrphans() ([]*Migration, error) {
+	_, orphans, err := m.getAllMigrationsAndOrphans(Up)
+
+	return orphans, err
+}
+
+// PruneHistory removes orphans from the history and returns them.
+func (m *Migrataur) PruneHistory() ([]*Migration, error) {
+	m.step("Pruning the history")
+
+	orphans, err := m.GetOrphans()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orphans) == 0 {
+		m.info("All clear, nothing done!")
+		return orphans, nil
+	}
+
+	for i, orphan := range orphans {
+		if err = m.adapter.MigrationRollbacked(orphan); err != nil {
+			return orphans[:i], m.fail(err)
+		}
+
+		m.emit(Event{Kind: EventInfo, Message: fmt.Sprintf("%s removed from the history", orphan.Name), Migration: orphan.Name})
+	}
+
+	return orphans, nil
+}
+
+// handleOrphans applies the configured OrphanPolicy
+func (m *Migrataur) handleOrphans(orphans []*Migration) error {
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	switch m.options.Orphans {
+	case OrphansIgnore:
+		return nil
+	case OrphansWarn:
+		for _, orphan := range orphans {
+			m.warn("%s is in the history but was not found in the migrations directory", orphan.Name)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("the migration %s was not found in the migrations directory, see prune-history", orphans[0].Name)
+	}
+}
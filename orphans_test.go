@@ -0,0 +1,71 @@
+package migrataur
+
+import "testing"
+
+func TestMigrataurOrphans(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "20180101120000_users.sql"},
+		mockFileInfo{name: "20180102120000_roles.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.Migrate("users")
+
+	assert.nil(err)
+
+	// The file of an applied migration has been deleted by hand
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "20180102120000_roles.sql"},
+	)
+
+	_, err = instance.MigrateToLatest()
+
+	assert.
+		notNil(err).
+		contains("20180101120000_users.sql", err.Error())
+
+	migrations, err := instance.GetAll()
+
+	assert.
+		nil(err).
+		equals(2, len(migrations)).
+		false(migrations[0].IsOrphan()).
+		true(migrations[1].IsOrphan()).
+		contains("[!]", migrations[1].String())
+
+	opts := DefaultOptions
+	opts.Orphans = OrphansWarn
+	warning := New(adapter, opts)
+
+	applied, err := warning.MigrateToLatest()
+
+	assert.
+		nil(err).
+		equals(1, len(applied))
+
+	opts.Orphans = OrphansIgnore
+	_, err = New(adapter, opts).Rollback("roles")
+
+	assert.nil(err)
+
+	orphans, err := instance.GetOrphans()
+
+	assert.
+		nil(err).
+		equals(1, len(orphans)).
+		equals("20180101120000_users.sql", orphans[0].Name)
+
+	pruned, err := instance.PruneHistory()
+
+	assert.
+		nil(err).
+		equals(1, len(pruned)).
+		equals(0, len(adapter.appliedMigrations))
+
+	_, err = instance.MigrateToLatest()
+
+	assert.nil(err)
+}
@@ -0,0 +1,45 @@
+package migrataur
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Options retrieves a copy of the options used by the instance. Change them with the
+// Override* methods, others can not be safely changed once the instance is constructed.
+func (m *Migrataur) Options() Options {
+	return m.options
+}
+
+// OverrideDirectory changes the directory containing migrations of this set only. It must
+// not be called while a command is running.
+func (m *Migrataur) OverrideDirectory(directory string) error {
+	if directory == "" {
+		return fmt.Errorf("the migrations directory can not be empty")
+	}
+
+	absPath, err := filepath.Abs(directory)
+
+	if err != nil {
+		return err
+	}
+
+	m.options.Directory = absPath
+
+	return nil
+}
+
+// OverrideEventHandler changes the EventHandler of every set. Give nil to output events
+// to the Logger again.
+func (m *Migrataur) OverrideEventHandler(handler EventHandler) {
+	for _, set := range m.root.sets {
+		set.options.EventHandler = handler
+	}
+}
+
+// OverrideMinLevel changes the Options.MinLevel of every set.
+func (m *Migrataur) OverrideMinLevel(level Level) {
+	for _, set := range m.root.sets {
+		set.options.MinLevel = level
+	}
+}
@@ -0,0 +1,60 @@
+package migrataur
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+// ProtectedEnvVar marks the environment as protected when set to a true value, such as
+// MIGRATAUR_PROTECTED=1 on production servers.
+const ProtectedEnvVar = "MIGRATAUR_PROTECTED"
+
+// ErrProtectedEnvironment is returned by Reset when the environment is protected, see
+// Options.Protected and AllowProtected.
+var ErrProtectedEnvironment = errors.New("the environment is protected, resetting the database is not allowed")
+
+// IsProtected checks if the environment is protected, either with Options.Protected or the
+// MIGRATAUR_PROTECTED environment variable.
+func (m *Migrataur) IsProtected() bool {
+	if m.options.Protected {
+		return true
+	}
+
+	protected, _ := strconv.ParseBool(os.Getenv(ProtectedEnvVar))
+
+	return protected
+}
+
+// PlanReset retrieves migrations Reset would rollback, in the order they would be.
+func (m *Migrataur) PlanReset() ([]*Migration, error) {
+	migrations, err := m.getAllMigrations(Down)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return filterMigrations(migrations, (*Migration).HasBeenApplied), nil
+}
+
+// PlanRemove retrieves migrations Remove would delete, in the order they would be. Applied
+// ones will be rolled back first.
+func (m *Migrataur) PlanRemove(rangeOrName string) ([]*Migration, error) {
+	all, err := m.getAllMigrations(Down)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.selectMigrations(all, rangeOrName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkDependents(all, migrations); err != nil {
+		return nil, m.fail(err)
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,59 @@
+package migrataur
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMigrataurProtectedReset(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	opts := DefaultOptions
+	opts.Protected = true
+	instance := New(adapter, opts)
+
+	_, err := instance.Migrate("migration01..migration02")
+
+	assert.nil(err)
+
+	plan, err := instance.PlanReset()
+
+	assert.
+		nil(err).
+		equals(2, len(plan)).
+		equals("migration02.sql", plan[0].Name)
+
+	plan, err = instance.PlanRemove("migration03..migration02")
+
+	assert.
+		nil(err).
+		equals(2, len(plan)).
+		equals("migration03.sql", plan[0].Name)
+
+	_, err = instance.Reset()
+
+	assert.
+		equals(ErrProtectedEnvironment, err).
+		equals(2, len(adapter.appliedMigrations))
+
+	unprotected := New(adapter, DefaultOptions)
+
+	os.Setenv(ProtectedEnvVar, "true")
+	_, err = unprotected.Reset()
+	os.Unsetenv(ProtectedEnvVar)
+
+	assert.equals(ErrProtectedEnvironment, err)
+
+	rolledBack, err := instance.Reset(AllowProtected())
+
+	assert.
+		nil(err).
+		equals(2, len(rolledBack)).
+		equals(0, len(adapter.appliedMigrations))
+}
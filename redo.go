@@ -0,0 +1,80 @@
+package migrataur
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Redo rollbacks the last n applied migrations, by application date, and applies them again
+// after reading their files once more. It is meant to iterate on a migration locally. The
+// whole operation holds the adapter lock if it implements Locker, waiting for it at most
+// Options.LockTimeout. Returns migrations applied again.
+func (m *Migrataur) Redo(n int, opts ...RunOption) ([]*Migration, error) {
+	m.step("Redoing the last %d migration(s)", n)
+
+	if n < 1 {
+		return nil, m.fail(fmt.Errorf("\tThe number of migrations to redo must be at least 1"))
+	}
+
+	timeout := m.options.LockTimeout
+
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+
+	release, err := m.acquireLock(0, timeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	runOpts := buildRunOptions(opts)
+	all, err := m.getAllMigrations(Down)
+
+	if err != nil {
+		return nil, err
+	}
+
+	selected := filterMigrations(all, func(mig *Migration) bool {
+		return mig.HasBeenApplied() && !mig.IsInitial()
+	})
+
+	// The last applied ones may not be the last by name, when a branch has been merged late
+	sort.Sort(sort.Reverse(byAppliedAt(selected)))
+
+	if len(selected) > n {
+		selected = selected[:n]
+	}
+
+	if len(selected) == 0 {
+		m.info("All clear, nothing done!")
+		return []*Migration{}, nil
+	}
+
+	if err = checkDependencies(all, selected, Down); err != nil {
+		return nil, m.fail(err)
+	}
+
+	rollbacked, err := m.apply(selected, Down, runOpts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+
+	for _, mig := range rollbacked {
+		names[mig.Name] = true
+	}
+
+	// Read files again since they have probably been edited
+	all, err = m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.apply(filterMigrations(all, func(mig *Migration) bool { return names[mig.Name] }), Up, runOpts)
+}
@@ -0,0 +1,366 @@
+package migrataur
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rename renames a migration file and, if it has been applied, its row in the history. The
+// new name may be given without the extension. Migrations depending on it are updated too.
+// The previous name is kept in the header so that other databases have their history
+// rewritten the next time migrations are applied, or by UpgradeHistory.
+func (m *Migrataur) Rename(oldName, newName string) (*Migration, error) {
+	m.step("Renaming %s to %s", oldName, newName)
+
+	all, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := findMigration(all, oldName, m.options.ExactNames)
+
+	if err != nil {
+		return nil, m.fail(err)
+	}
+
+	if idx == -1 {
+		return nil, m.fail(fmt.Errorf("\tCould not find the migration %s", oldName))
+	}
+
+	if strings.ContainsAny(newName, `/\`) {
+		return nil, m.fail(fmt.Errorf("\tThe name %s should not contain a path separator", newName))
+	}
+
+	if filepath.Ext(newName) == "" {
+		newName += m.options.Extension
+	}
+
+	migration := all[idx]
+
+	if err = m.rename(all, []Rename{{From: migration.Name, To: newName}}); err != nil {
+		return nil, err
+	}
+
+	return migration, nil
+}
+
+// Renumber renames every migration to use zero padded incremental sequences, such as
+// 0001_createMigrationHistory.sql, keeping their current order. Use it when switching to
+// the IncrementalSequence generator. Returns renamed migrations.
+func (m *Migrataur) Renumber(width int) ([]*Migration, error) {
+	m.step("Renumbering migrations")
+
+	all, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	renames := []Rename{}
+	renamed := []*Migration{}
+
+	for i, mig := range all {
+		name := fmt.Sprintf("%0*d_%s%s", width, i+1, nameWithoutSequence(mig.Name), filepath.Ext(mig.Name))
+
+		if name != mig.Name {
+			renames = append(renames, Rename{From: mig.Name, To: name})
+			renamed = append(renamed, mig)
+		}
+	}
+
+	if len(renames) == 0 {
+		m.info("All clear, nothing done!")
+		return renamed, nil
+	}
+
+	if err = m.rename(all, renames); err != nil {
+		return nil, err
+	}
+
+	return renamed, nil
+}
+
+// renamingSuffix is appended to files being renamed so that a migration can take the name
+// of another one renamed in the same batch
+const renamingSuffix = ".renaming"
+
+// rename writes the previous names in the headers first, then applies given renames to the
+// history, since it is atomic if the adapter supports it, and finally to files. If any of
+// those steps fails, the previous ones are reverted.
+func (m *Migrataur) rename(all []*Migration, renames []Rename) error {
+	index := indexMigrations(all)
+	applied := []Rename{}
+	finalNames := map[string]string{}
+
+	for _, mig := range all {
+		finalNames[mig.Name] = mig.Name
+	}
+
+	for _, r := range renames {
+		delete(finalNames, r.From)
+
+		if index[r.From].HasBeenApplied() {
+			applied = append(applied, r)
+		}
+	}
+
+	// Check collisions against names as they will be once everything has been renamed
+	for _, r := range renames {
+		if _, exists := finalNames[r.To]; exists {
+			return m.fail(fmt.Errorf("\tThe migration %s already exists", r.To))
+		}
+
+		finalNames[r.To] = r.From
+	}
+
+	// Since migrations are sorted by name, a sequence can not be shared, see writeNewMigration
+	for _, r := range renames {
+		sequence := migrationSequence(r.To)
+
+		if sequence == "" {
+			continue
+		}
+
+		for name := range finalNames {
+			if name != r.To && migrationSequence(name) == sequence {
+				return m.fail(fmt.Errorf("\tThe sequence %s is already used by %s", sequence, name))
+			}
+		}
+	}
+
+	renamer, ok := m.adapter.(HistoryRenamer)
+
+	if len(applied) > 0 && !ok {
+		return m.fail(fmt.Errorf("\tThe adapter does not support renaming applied migrations"))
+	}
+
+	// Other databases will catch up thanks to the previous name written in the header
+	originals, err := m.writeRenamedFrom(index, renames)
+
+	if err != nil {
+		return m.fail(err)
+	}
+
+	if len(applied) > 0 {
+		if err = renamer.RenameMigrations(throughTemporaryNames(applied)); err != nil {
+			m.restoreFiles(originals)
+			return m.fail(err)
+		}
+	}
+
+	if err = m.renameFiles(renames); err != nil {
+		m.revertHistory(applied, renamer)
+		m.restoreFiles(originals)
+		return m.fail(err)
+	}
+
+	for _, r := range renames {
+		mig := index[r.From]
+		mig.Name = r.To
+		mig.renamedFrom = append(mig.renamedFrom, r.From)
+
+		m.emit(Event{Kind: EventInfo, Message: fmt.Sprintf("%s renamed to %s", r.From, r.To), Migration: r.To})
+	}
+
+	return m.updateReferences(all, renames)
+}
+
+// writeRenamedFrom adds the previous name to the header of every renamed file, before it is
+// moved. Returns the original contents by path so that they can be restored.
+func (m *Migrataur) writeRenamedFrom(index map[string]*Migration, renames []Rename) (map[string][]byte, error) {
+	originals := map[string][]byte{}
+
+	if m.options.MarshalOptions.RenamedFrom == "" {
+		return originals, nil
+	}
+
+	for _, r := range renames {
+		path := m.getMigrationFullpath(r.From)
+		data, err := fsAdapter.ReadFile(path)
+
+		if err != nil {
+			m.restoreFiles(originals)
+			return nil, err
+		}
+
+		renamedFrom := append(append([]string{}, index[r.From].renamedFrom...), r.From)
+
+		if err = writeFile(path, setDirective(data, m.options.MarshalOptions, m.options.MarshalOptions.RenamedFrom, renamedFrom)); err != nil {
+			// The file may have been truncated
+			originals[path] = data
+			m.restoreFiles(originals)
+			return nil, err
+		}
+
+		originals[path] = data
+	}
+
+	return originals, nil
+}
+
+// restoreFiles writes back the original contents of files
+func (m *Migrataur) restoreFiles(originals map[string][]byte) {
+	for path, data := range originals {
+		if err := writeFile(path, data); err != nil {
+			m.warn("Could not restore the content of %s: %s", path, err)
+		}
+	}
+}
+
+// renameFiles moves every file to a temporary name first, then to its new name. If a file
+// could not be moved, those already moved are put back.
+func (m *Migrataur) renameFiles(renames []Rename) error {
+	moved := []Rename{}
+
+	for _, r := range throughTemporaryNames(renames) {
+		from, to := m.getMigrationFullpath(r.From), m.getMigrationFullpath(r.To)
+
+		if err := fsAdapter.Rename(from, to); err != nil {
+			m.revertFiles(moved)
+			return err
+		}
+
+		moved = append(moved, Rename{From: from, To: to})
+	}
+
+	return nil
+}
+
+// throughTemporaryNames splits renames in two steps, so that applying them in order never
+// gives a migration the name of another one which has not been renamed yet
+func throughTemporaryNames(renames []Rename) []Rename {
+	result := make([]Rename, 0, 2*len(renames))
+
+	for _, r := range renames {
+		result = append(result, Rename{From: r.From, To: r.From + renamingSuffix})
+	}
+
+	for _, r := range renames {
+		result = append(result, Rename{From: r.From + renamingSuffix, To: r.To})
+	}
+
+	return result
+}
+
+// revertFiles puts back moved files, in the reverse order
+func (m *Migrataur) revertFiles(moved []Rename) {
+	for i := len(moved) - 1; i >= 0; i-- {
+		if err := fsAdapter.Rename(moved[i].To, moved[i].From); err != nil {
+			m.warn("Could not rename %s back to %s: %s", moved[i].To, moved[i].From, err)
+		}
+	}
+}
+
+// revertHistory puts back the history as it was
+func (m *Migrataur) revertHistory(applied []Rename, renamer HistoryRenamer) {
+	if len(applied) == 0 {
+		return
+	}
+
+	reverted := make([]Rename, len(applied))
+
+	for i, r := range applied {
+		reverted[i] = Rename{From: r.To, To: r.From}
+	}
+
+	if err := renamer.RenameMigrations(throughTemporaryNames(reverted)); err != nil {
+		m.warn("Could not revert the history: %s", err)
+	}
+}
+
+// updateReferences rewrites migrations depending on, or replacing, renamed ones and the
+// list of migrations included in the schema snapshot. Only directive lines are patched.
+func (m *Migrataur) updateReferences(all []*Migration, renames []Rename) error {
+	newNames := map[string]string{}
+
+	for _, r := range renames {
+		newNames[r.From] = r.To
+		newNames[nameWithoutExtension(r.From)] = nameWithoutExtension(r.To)
+	}
+
+	for _, mig := range all {
+		dependsOnChanged := renameReferences(mig.dependsOn, newNames)
+		replacesChanged := renameReferences(mig.replaces, newNames)
+
+		if !dependsOnChanged && !replacesChanged {
+			continue
+		}
+
+		path := m.getMigrationFullpath(mig.Name)
+		data, err := fsAdapter.ReadFile(path)
+
+		if err != nil {
+			return m.fail(err)
+		}
+
+		if dependsOnChanged {
+			data = setDirective(data, m.options.MarshalOptions, m.options.MarshalOptions.DependsOn, mig.dependsOn)
+		}
+
+		if replacesChanged {
+			data = setDirective(data, m.options.MarshalOptions, m.options.MarshalOptions.Replaces, mig.replaces)
+		}
+
+		if err = writeFile(path, data); err != nil {
+			return m.fail(err)
+		}
+
+		m.emit(Event{Kind: EventInfo, Message: fmt.Sprintf("%s references updated", mig.Name), Migration: mig.Name})
+	}
+
+	return m.updateSchemaReferences(newNames)
+}
+
+// updateSchemaReferences rewrites the list of migrations included in the schema snapshot
+func (m *Migrataur) updateSchemaReferences(newNames map[string]string) error {
+	if m.options.SchemaFile == "" {
+		return nil
+	}
+
+	data, err := fsAdapter.ReadFile(m.options.SchemaFile)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return m.fail(err)
+	}
+
+	snapshot := &Migration{Name: filepath.Base(m.options.SchemaFile)}
+
+	if err = snapshot.unmarshal(data, m.options.MarshalOptions); err != nil {
+		return m.fail(err)
+	}
+
+	if !renameReferences(snapshot.replaces, newNames) {
+		return nil
+	}
+
+	data = setDirective(data, m.options.MarshalOptions, m.options.MarshalOptions.Replaces, snapshot.replaces)
+
+	if err = writeFile(m.options.SchemaFile, data); err != nil {
+		return m.fail(err)
+	}
+
+	m.info("%s references updated", snapshot.Name)
+
+	return nil
+}
+
+// renameReferences replaces, in place, renamed names of the list and tells if any has been
+func renameReferences(names []string, newNames map[string]string) bool {
+	changed := false
+
+	for i, name := range names {
+		if newName, ok := newNames[name]; ok {
+			names[i] = newName
+			changed = true
+		}
+	}
+
+	return changed
+}
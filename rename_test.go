@@ -0,0 +1,321 @@
+package migrataur
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrataurRename(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "20180101120000_users.sql"},
+		mockFileInfo{name: "20180102120000_roles.sql", content: `-- +migrataur depends-on: 20180101120000_users
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.Migrate("users")
+
+	assert.nil(err)
+
+	renamed, err := instance.Rename("users", "20180101120000_accounts")
+
+	assert.
+		nil(err).
+		equals("20180101120000_accounts.sql", renamed.Name).
+		true(mockFSAdapter.exists("20180101120000_accounts.sql")).
+		false(mockFSAdapter.exists("20180101120000_users.sql")).
+		equals("20180101120000_accounts.sql", adapter.appliedMigrations[0].Name).
+		contains("depends-on: 20180101120000_accounts\n", mockFSAdapter.content("20180102120000_roles.sql")).
+		contains("renamed-from: 20180101120000_users.sql\n", mockFSAdapter.content("20180101120000_accounts.sql"))
+
+	_, err = instance.Rename("accounts", "20180102120000_roles")
+
+	assert.notNil(err)
+
+	migrations, err := instance.GetAll()
+
+	assert.
+		nil(err).
+		true(migrations[0].HasBeenApplied()).
+		false(migrations[1].HasBeenApplied())
+
+	// Without HistoryRenamer, only pending migrations can be renamed
+	withoutRenamer := New(struct{ Adapter }{adapter}, DefaultOptions)
+
+	_, err = withoutRenamer.Rename("accounts", "20180101120000_users")
+
+	assert.notNil(err)
+
+	_, err = withoutRenamer.Rename("roles", "20180102120000_permissions")
+
+	assert.
+		nil(err).
+		true(mockFSAdapter.exists("20180102120000_permissions.sql"))
+}
+
+func TestMigrataurRenumber(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "20180101120000_users.sql"},
+		mockFileInfo{name: "20180102120000_roles.sql"},
+		mockFileInfo{name: "20180103120000_movies.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.Migrate("users..roles")
+
+	assert.nil(err)
+
+	renamed, err := instance.Renumber(4)
+
+	assert.
+		nil(err).
+		equals(3, len(renamed)).
+		equals("0001_users.sql", renamed[0].Name).
+		equals("0003_movies.sql", renamed[2].Name).
+		true(mockFSAdapter.exists("0002_roles.sql")).
+		equals("0001_users.sql", adapter.appliedMigrations[0].Name).
+		equals("0002_roles.sql", adapter.appliedMigrations[1].Name)
+
+	renamed, err = instance.Renumber(4)
+
+	assert.
+		nil(err).
+		equals(0, len(renamed))
+
+	_, err = New(struct{ Adapter }{newMockAdapter()}, DefaultOptions).Renumber(3)
+
+	assert.nil(err)
+}
+
+func TestMigrataurRenameSwappingNames(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "0001_users.sql"},
+		mockFileInfo{name: "0003_roles.sql"},
+		mockFileInfo{name: "0002_movies.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	// Targets are names of other migrations renamed in the same batch
+	migrations, err := instance.getAllMigrations(Up)
+
+	assert.nil(err)
+
+	err = instance.rename(migrations, []Rename{
+		{From: "0002_movies.sql", To: "0003_movies.sql"},
+		{From: "0003_roles.sql", To: "0002_movies.sql"},
+	})
+
+	assert.
+		nil(err).
+		exists("0003_movies.sql").
+		exists("0002_movies.sql").
+		notExists("0003_roles.sql").
+		applied(adapter.appliedMigrations, "0001_users", "0003_movies", "0002_movies")
+
+	migrations, err = instance.getAllMigrations(Up)
+
+	assert.nil(err)
+
+	err = instance.rename(migrations, []Rename{
+		{From: "0002_movies.sql", To: "0001_users.sql"},
+	})
+
+	assert.notNil(err)
+}
+
+func TestMigrataurRenameUpdatesReplaces(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "0001_users.sql"},
+		mockFileInfo{name: "0002_roles.sql", content: `-- +migrataur depends-on: 0001_users
+-- +migrataur replaces: 0001_users.sql, 0001_accounts.sql
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	opts := DefaultOptions
+	opts.SchemaFile = filepath.Join(DefaultOptions.Directory, "schema.sql")
+	instance := New(adapter, opts)
+
+	_, err := instance.MigrateToLatest()
+
+	assert.
+		nil(err).
+		contains("replaces: 0001_users.sql, 0002_roles.sql", mockFSAdapter.content("schema.sql"))
+
+	_, err = instance.Rename("users", "0001_people")
+
+	assert.
+		nil(err).
+		contains("depends-on: 0001_people\n", mockFSAdapter.content("0002_roles.sql")).
+		contains("replaces: 0001_people.sql, 0001_accounts.sql\n", mockFSAdapter.content("0002_roles.sql")).
+		contains("replaces: 0001_people.sql, 0002_roles.sql", mockFSAdapter.content("schema.sql"))
+}
+
+func TestMigrataurRenameCatchUp(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "20180101120000_users.sql"},
+		mockFileInfo{name: "20180102120000_roles.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	// Keep a copy of the history as another database would have it
+	otherAdapter := newMockAdapter()
+
+	for _, mig := range adapter.appliedMigrations {
+		row := *mig
+		otherAdapter.appliedMigrations = append(otherAdapter.appliedMigrations, &row)
+	}
+
+	_, err = instance.Renumber(4)
+
+	assert.
+		nil(err).
+		contains("renamed-from: 20180101120000_users.sql\n", mockFSAdapter.content("0001_users.sql"))
+
+	opts := DefaultOptions
+	opts.Orphans = OrphansIgnore
+	other := New(otherAdapter, opts)
+
+	migrations, err := other.GetAll()
+
+	assert.
+		nil(err).
+		equals(2, len(migrations)).
+		applied(migrations, "0001_users", "0002_roles").
+		applied(otherAdapter.appliedMigrations, "20180101120000_users", "20180102120000_roles")
+
+	applied, err := other.MigrateToLatest()
+
+	assert.
+		nil(err).
+		equals(0, len(applied)).
+		applied(otherAdapter.appliedMigrations, "0001_users", "0002_roles")
+}
+
+func TestMigrataurRenameKeepsComments(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "0001_users.sql", content: `-- Users of the application
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`},
+		mockFileInfo{name: "0002_roles.sql", content: `-- Roles need users
+-- +migrataur depends-on: 0001_users
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`},
+	)
+
+	assert := assert(t)
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	_, err := instance.Rename("users", "0001_people")
+
+	assert.
+		nil(err).
+		equals(`-- Users of the application
+-- +migrataur renamed-from: 0001_users.sql
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`, mockFSAdapter.content("0001_people.sql")).
+		equals(`-- Roles need users
+-- +migrataur depends-on: 0001_people
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`, mockFSAdapter.content("0002_roles.sql"))
+}
+
+// renameFailingAdapter can not rename its history
+type renameFailingAdapter struct {
+	*mockAdapter
+}
+
+func (a *renameFailingAdapter) RenameMigrations(renames []Rename) error {
+	return fmt.Errorf("database is unreachable")
+}
+
+func TestMigrataurRenameFailures(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "0001_users.sql"},
+		mockFileInfo{name: "0002_roles.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(&renameFailingAdapter{mockAdapter: adapter}, DefaultOptions)
+
+	_, err := instance.Rename("roles", "0001_roles")
+
+	assert.
+		contains("The sequence 0001 is already used by 0001_users.sql", err.Error()).
+		exists("0002_roles.sql")
+
+	_, err = instance.Rename("roles", "../0002_roles")
+
+	assert.
+		contains("should not contain a path separator", err.Error()).
+		exists("0002_roles.sql")
+
+	_, err = instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	// The renamed-from header is written first, then put back when the history fails
+	_, err = instance.Rename("roles", "0003_roles")
+
+	assert.
+		contains("database is unreachable", err.Error()).
+		exists("0002_roles.sql").
+		notExists("0003_roles.sql").
+		equals(mockMigrationContent, mockFSAdapter.content("0002_roles.sql")).
+		applied(adapter.appliedMigrations, "0001_users", "0002_roles")
+}
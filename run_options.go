@@ -0,0 +1,63 @@
+package migrataur
+
+// RunOption alters the way migrations are applied or rolled back during a single call
+// to Migrate, MigrateToLatest, Rollback, Reset or Remove.
+type RunOption func(*runOptions)
+
+// runOptions holds the resolved RunOption for a single run
+type runOptions struct {
+	// fake only records migrations in the history without executing their commands
+	fake bool
+	// allowIrreversible rollbacks irreversible migrations instead of failing
+	allowIrreversible bool
+	// runID is recorded in the history for every migration applied during the run
+	runID string
+	// allowProtected resets the database even if the environment is protected
+	allowProtected bool
+}
+
+// Fake only updates the history: the adapter will be notified that migrations have been
+// applied or rolled back but their commands will NOT be executed. Use it to reconcile the
+// history after applying changes by hand. The initial migration is still executed when
+// applied since it creates the history.
+func Fake() RunOption {
+	return func(opts *runOptions) {
+		opts.fake = true
+	}
+}
+
+// AllowIrreversible allows rolling back migrations marked as irreversible. Their down section,
+// if any, is executed and they are removed from the history. Without it, rolling back such
+// a migration fails with an *IrreversibleError.
+func AllowIrreversible() RunOption {
+	return func(opts *runOptions) {
+		opts.allowIrreversible = true
+	}
+}
+
+// WithRunID records the given identifier in the history alongside every migration applied
+// during the run, useful to know which deployment applied what.
+func WithRunID(id string) RunOption {
+	return func(opts *runOptions) {
+		opts.runID = id
+	}
+}
+
+// AllowProtected allows Reset to run even if the environment is protected. Without it,
+// Reset fails with ErrProtectedEnvironment.
+func AllowProtected() RunOption {
+	return func(opts *runOptions) {
+		opts.allowProtected = true
+	}
+}
+
+// buildRunOptions resolves given options
+func buildRunOptions(opts []RunOption) runOptions {
+	result := runOptions{}
+
+	for _, opt := range opts {
+		opt(&result)
+	}
+
+	return result
+}
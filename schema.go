@@ -0,0 +1,157 @@
+package migrataur
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// DumpSchema writes a snapshot of the database schema to the configured SchemaFile. The
+// snapshot also lists applied migrations so LoadSchema can mark them as applied. The adapter
+// must implement SchemaDumper.
+func (m *Migrataur) DumpSchema() error {
+	if m.options.SchemaFile == "" {
+		return fmt.Errorf("no schema file has been configured")
+	}
+
+	dumper, ok := m.adapter.(SchemaDumper)
+
+	if !ok {
+		return fmt.Errorf("the adapter does not support schema dumps")
+	}
+
+	m.step("Dumping schema to %s", m.options.SchemaFile)
+
+	migrations, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return err
+	}
+
+	schema, err := dumper.DumpSchema()
+
+	if err != nil {
+		return err
+	}
+
+	// A snapshot is nothing more than a migration replacing every applied one
+	snapshot := &Migration{
+		Name:     filepath.Base(m.options.SchemaFile),
+		up:       schema,
+		replaces: []string{},
+	}
+
+	for _, mig := range migrations {
+		if mig.HasBeenApplied() {
+			snapshot.replaces = append(snapshot.replaces, mig.Name)
+		}
+	}
+
+	if err = snapshot.writeTo(m.options.SchemaFile, m.options.MarshalOptions); err != nil {
+		return err
+	}
+
+	m.info("%s written!", snapshot.Name)
+
+	return nil
+}
+
+// LoadSchema bootstraps a fresh database from the configured SchemaFile and marks migrations
+// included in the snapshot as applied, without executing them. The initial migration is the
+// only one really executed, before the snapshot, so that the history exists. Remaining
+// migrations can then be applied with MigrateToLatest.
+func (m *Migrataur) LoadSchema() ([]*Migration, error) {
+	if m.options.SchemaFile == "" {
+		return nil, fmt.Errorf("no schema file has been configured")
+	}
+
+	m.step("Loading schema from %s", m.options.SchemaFile)
+
+	history, err := m.adapter.GetAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(history) > 0 {
+		return nil, m.fail(fmt.Errorf("\tThe database already has applied migrations, a schema can only be loaded in a fresh one"))
+	}
+
+	data, err := fsAdapter.ReadFile(m.options.SchemaFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Migration{Name: filepath.Base(m.options.SchemaFile)}
+
+	if err = snapshot.unmarshal(data, m.options.MarshalOptions); err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := indexMigrations(migrations)
+	included := []*Migration{}
+
+	for _, name := range snapshot.replaces {
+		mig, ok := index[name]
+
+		if !ok {
+			return nil, m.fail(fmt.Errorf("\tThe migration %s included in the schema was not found in the migrations directory", name))
+		}
+
+		included = append(included, mig)
+	}
+
+	// The snapshot does not include the history, the initial migration must really be
+	// executed for the others to be recorded
+	initial := filterMigrations(included, m.isInitialMigration)
+	loaded := []*Migration{}
+
+	for _, mig := range initial {
+		ok, err := m.applyOne(mig, Up, runOptions{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			loaded = append(loaded, mig)
+		}
+	}
+
+	if err = m.adapter.Exec(snapshot.up); err != nil {
+		m.emit(Event{Kind: EventFailure, Message: "schema loading failed", Migration: snapshot.Name, Err: err})
+		return nil, err
+	}
+
+	m.migrationEvent(EventMigrationApplied, snapshot, "schema loaded")
+
+	applied, err := m.apply(filterMigrations(included, func(mig *Migration) bool {
+		return !m.isInitialMigration(mig)
+	}), Up, runOptions{fake: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(loaded, applied...), nil
+}
+
+// dumpSchemaIfNeeded writes the schema snapshot when configured to do so
+func (m *Migrataur) dumpSchemaIfNeeded() error {
+	if m.options.SchemaFile == "" {
+		return nil
+	}
+
+	if _, ok := m.adapter.(SchemaDumper); !ok {
+		m.warn("The adapter does not support schema dumps, skipping it")
+		return nil
+	}
+
+	return m.DumpSchema()
+}
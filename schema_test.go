@@ -0,0 +1,95 @@
+package migrataur
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrataurSchemaDump(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	adapter.schema = "create table horses(name varchar(50));"
+
+	opts := DefaultOptions
+	opts.SchemaFile = filepath.Join(DefaultOptions.Directory, "schema.sql")
+
+	instance := New(adapter, opts)
+
+	_, err := instance.Migrate("migration01..migration02")
+
+	assert.
+		nil(err).
+		notExists("schema.sql")
+
+	_, err = instance.MigrateToLatest()
+
+	assert.
+		nil(err).
+		exists("schema.sql").
+		contains("-- +migrataur replaces: migration01.sql, migration02.sql, migration03.sql", mockFSAdapter.content("schema.sql")).
+		contains(adapter.schema, mockFSAdapter.content("schema.sql"))
+
+	_, err = instance.LoadSchema()
+
+	assert.notNil(err)
+
+	// Let's add a migration not yet included in the snapshot
+	mockFSAdapter.files = append(mockFSAdapter.files, mockFileInfo{name: "migration04.sql"})
+
+	freshAdapter := newMockAdapter()
+	fresh := New(freshAdapter, opts)
+
+	applied, err := fresh.LoadSchema()
+
+	assert.
+		nil(err).
+		applied(applied, "migration01", "migration02", "migration03").
+		equals(1, len(freshAdapter.executed)).
+		equals(adapter.schema, freshAdapter.executed[0])
+
+	applied, err = fresh.MigrateToLatest()
+
+	assert.
+		nil(err).
+		applied(applied, "migration04")
+}
+
+func TestMigrataurLoadSchemaWithInitialMigration(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration00_" + DefaultOptions.InitialMigrationName + ".sql", content: `-- +migrataur up
+create history
+-- -migrataur up`},
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	adapter.schema = "create table horses(name varchar(50));"
+
+	opts := DefaultOptions
+	opts.SchemaFile = filepath.Join(DefaultOptions.Directory, "schema.sql")
+
+	_, err := New(adapter, opts).MigrateToLatest()
+
+	assert.nil(err)
+
+	fresh := &freshAdapter{mockAdapter: newMockAdapter()}
+	applied, err := New(fresh, opts).LoadSchema()
+
+	assert.
+		nil(err).
+		applied(applied, DefaultOptions.InitialMigrationName, "migration01", "migration02").
+		false(applied[0].Fake).
+		true(applied[1].Fake).
+		equals(2, len(fresh.executed)).
+		equals("create history", fresh.executed[0]).
+		equals(adapter.schema, fresh.executed[1]).
+		equals(3, len(fresh.appliedMigrations))
+}
@@ -0,0 +1,305 @@
+package migrataur
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// TagPrefix starts a selector term matching every migration having the given tag
+const TagPrefix = "tag:"
+
+// ExactPrefix starts a selector pattern which must match a migration name exactly
+const ExactPrefix = "="
+
+// Selector picks migrations among those known by an instance. It is parsed from an
+// expression made of comma separated terms, each one being:
+//
+//	migration01           a single migration, see below for how names are matched
+//	=migration01          a single migration, without substring matching
+//	migration01..migration05
+//	migration01..         a range, open ranges go up to the first or last migration
+//	..migration05
+//	2018*_users*          every migration matching the glob pattern
+//	tag:seed              every migration tagged with seed
+//
+// A name matches a migration if it is its full name or its name without the extension,
+// otherwise its name without the sequence prefix, otherwise if it is contained in a single
+// migration name. When many migrations match at the same step, an *AmbiguousError listing
+// them is returned. Use RequireExactNames to disable substring matching.
+//
+// Ranges follow the order in which migrations are run: oldest first when applying them,
+// newest first when rolling them back.
+type Selector struct {
+	terms []selectorTerm
+	// exactOnly disables substring matching
+	exactOnly bool
+}
+
+type selectorTermKind int
+
+const (
+	nameTerm selectorTermKind = iota
+	rangeTerm
+	globTerm
+	tagTerm
+)
+
+type selectorTerm struct {
+	kind       selectorTermKind
+	start, end string
+}
+
+// AmbiguousError is returned when a name matches many migrations.
+type AmbiguousError struct {
+	Name       string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%s is ambiguous, it matches: %s", e.Name, strings.Join(e.Candidates, ", "))
+}
+
+// ParseSelector parses the given expression. An empty one selects nothing.
+func ParseSelector(expr string) (*Selector, error) {
+	selector := &Selector{}
+
+	if strings.TrimSpace(expr) == "" {
+		return selector, nil
+	}
+
+	for _, part := range strings.Split(expr, ",") {
+		term, err := parseSelectorTerm(strings.TrimSpace(part))
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %s: %s", expr, err)
+		}
+
+		selector.terms = append(selector.terms, term)
+	}
+
+	return selector, nil
+}
+
+func parseSelectorTerm(term string) (selectorTerm, error) {
+	switch {
+	case term == "":
+		return selectorTerm{}, fmt.Errorf("empty term")
+	case strings.HasPrefix(term, TagPrefix):
+		tag := strings.TrimSpace(strings.TrimPrefix(term, TagPrefix))
+
+		if tag == "" {
+			return selectorTerm{}, fmt.Errorf("missing tag name")
+		}
+
+		return selectorTerm{kind: tagTerm, start: tag}, nil
+	case strings.Contains(term, ".."):
+		if strings.Count(term, "..") > 1 {
+			return selectorTerm{}, fmt.Errorf("%s has too many bounds", term)
+		}
+
+		start, end := getMigrationRange(term)
+
+		return selectorTerm{kind: rangeTerm, start: strings.TrimSpace(start), end: strings.TrimSpace(end)}, nil
+	case strings.ContainsAny(term, "*?["):
+		if _, err := path.Match(term, ""); err != nil {
+			return selectorTerm{}, fmt.Errorf("%s is not a valid pattern", term)
+		}
+
+		return selectorTerm{kind: globTerm, start: term}, nil
+	default:
+		return selectorTerm{kind: nameTerm, start: term}, nil
+	}
+}
+
+// RequireExactNames disables substring matching, names must then be full ones or the ones
+// after the sequence prefix.
+func (s *Selector) RequireExactNames() *Selector {
+	s.exactOnly = true
+
+	return s
+}
+
+// Select retrieves selected migrations among the given ones, in the same order. Every name
+// must match a migration or an error is returned.
+func (s *Selector) Select(migrations []*Migration) ([]*Migration, error) {
+	selected := map[*Migration]bool{}
+
+	for _, term := range s.terms {
+		matched, err := term.match(migrations, s.exactOnly)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mig := range matched {
+			selected[mig] = true
+		}
+	}
+
+	result := []*Migration{}
+
+	for _, mig := range migrations {
+		if selected[mig] {
+			result = append(result, mig)
+		}
+	}
+
+	return result, nil
+}
+
+func (t selectorTerm) match(migrations []*Migration, exactOnly bool) ([]*Migration, error) {
+	switch t.kind {
+	case tagTerm:
+		return filterMigrations(migrations, func(mig *Migration) bool { return mig.HasTag(t.start) }), nil
+	case globTerm:
+		return filterMigrations(migrations, func(mig *Migration) bool {
+			full, _ := path.Match(t.start, mig.Name)
+			short, _ := path.Match(t.start, nameWithoutExtension(mig.Name))
+
+			return full || short
+		}), nil
+	case rangeTerm:
+		return t.matchRange(migrations, exactOnly)
+	default:
+		idx, err := findMigration(migrations, t.start, exactOnly)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if idx == -1 {
+			return nil, fmt.Errorf("\tCould not find the migration %s", t.start)
+		}
+
+		return migrations[idx : idx+1], nil
+	}
+}
+
+func (t selectorTerm) matchRange(migrations []*Migration, exactOnly bool) ([]*Migration, error) {
+	idxStart, idxEnd := 0, len(migrations)-1
+
+	if t.start != "" {
+		idx, err := findMigration(migrations, t.start, exactOnly)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if idx == -1 {
+			return nil, fmt.Errorf("\tCould not find the lower bound %s", t.start)
+		}
+
+		idxStart = idx
+	}
+
+	if t.end != "" {
+		idx, err := findMigration(migrations[idxStart:], t.end, exactOnly)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if idx == -1 {
+			return nil, fmt.Errorf("\tCould not find the upper bound %s", t.end)
+		}
+
+		idxEnd = idxStart + idx
+	}
+
+	return migrations[idxStart : idxEnd+1], nil
+}
+
+// findMigration retrieves the index of the migration matching the given name, -1 if none.
+// Names are resolved in this order: full name (with or without the extension), name after
+// the sequence prefix and, unless exactOnly is true, unique substring.
+func findMigration(migrations []*Migration, name string, exactOnly bool) (int, error) {
+	exactOnly = exactOnly || strings.HasPrefix(name, ExactPrefix)
+	name = strings.TrimPrefix(name, ExactPrefix)
+
+	for i, mig := range migrations {
+		if mig.Name == name || nameWithoutExtension(mig.Name) == name {
+			return i, nil
+		}
+	}
+
+	candidates := []int{}
+
+	for i, mig := range migrations {
+		if nameWithoutSequence(mig.Name) == name {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if len(candidates) == 0 && !exactOnly {
+		for i, mig := range migrations {
+			if strings.Contains(mig.Name, name) {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return -1, nil
+	case 1:
+		return candidates[0], nil
+	}
+
+	names := make([]string, len(candidates))
+
+	for i, idx := range candidates {
+		names[i] = migrations[idx].Name
+	}
+
+	return -1, &AmbiguousError{Name: name, Candidates: names}
+}
+
+// filterMigrations retrieves migrations for which keep returns true
+func filterMigrations(migrations []*Migration, keep func(*Migration) bool) []*Migration {
+	result := []*Migration{}
+
+	for _, mig := range migrations {
+		if keep(mig) {
+			result = append(result, mig)
+		}
+	}
+
+	return result
+}
+
+// nameWithoutSequence strips the sequence prefix and the extension of a migration file name
+func nameWithoutSequence(name string) string {
+	name = nameWithoutExtension(name)
+
+	if idx := strings.Index(name, "_"); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
+
+// nameWithoutExtension strips the extension of a migration file name
+func nameWithoutExtension(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// selectMigrations parses the expression and selects migrations among the given ones
+func (m *Migrataur) selectMigrations(migrations []*Migration, expr string) ([]*Migration, error) {
+	selector, err := ParseSelector(expr)
+
+	if err != nil {
+		return nil, m.fail(err)
+	}
+
+	selector.exactOnly = m.options.ExactNames
+
+	selected, err := selector.Select(migrations)
+
+	if err != nil {
+		return nil, m.fail(err)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,212 @@
+package migrataur
+
+import "testing"
+
+func TestParseSelector(t *testing.T) {
+	assert := assert(t)
+
+	selector, err := ParseSelector("")
+
+	assert.
+		nil(err).
+		equals(0, len(selector.terms))
+
+	selector, err = ParseSelector("migration01, migration03..migration05,..migration02,migration04..,*_users,tag:seed,=migration06")
+
+	assert.
+		nil(err).
+		equals(7, len(selector.terms)).
+		equals(selectorTerm{kind: nameTerm, start: "migration01"}, selector.terms[0]).
+		equals(selectorTerm{kind: rangeTerm, start: "migration03", end: "migration05"}, selector.terms[1]).
+		equals(selectorTerm{kind: rangeTerm, end: "migration02"}, selector.terms[2]).
+		equals(selectorTerm{kind: rangeTerm, start: "migration04"}, selector.terms[3]).
+		equals(selectorTerm{kind: globTerm, start: "*_users"}, selector.terms[4]).
+		equals(selectorTerm{kind: tagTerm, start: "seed"}, selector.terms[5]).
+		equals(selectorTerm{kind: nameTerm, start: "=migration06"}, selector.terms[6])
+
+	for _, invalid := range []string{"a,,b", "a..b..c", "tag:", "[a"} {
+		_, err = ParseSelector(invalid)
+
+		assert.notNil(err)
+	}
+}
+
+func TestSelectorSelect(t *testing.T) {
+	all := []*Migration{
+		{Name: "20180101_users.sql"},
+		{Name: "20180102_user_roles.sql", tags: []string{"seed"}},
+		{Name: "20180103_movies.sql"},
+		{Name: "20180104_actors.sql", tags: []string{"seed"}},
+	}
+
+	assert := assert(t)
+
+	selectNames := func(expr string) ([]*Migration, error) {
+		selector, err := ParseSelector(expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return selector.Select(all)
+	}
+
+	selected, err := selectNames("movies,20180101_users")
+
+	assert.
+		nil(err).
+		applied(selected, "20180101_users", "20180103_movies")
+
+	selected, err = selectNames("user_roles..")
+
+	assert.
+		nil(err).
+		applied(selected, "20180102_user_roles", "20180103_movies", "20180104_actors")
+
+	selected, err = selectNames("..movies")
+
+	assert.
+		nil(err).
+		applied(selected, "20180101_users", "20180102_user_roles", "20180103_movies")
+
+	selected, err = selectNames("*_user*,tag:seed")
+
+	assert.
+		nil(err).
+		applied(selected, "20180101_users", "20180102_user_roles", "20180104_actors")
+
+	_, err = selectNames("user")
+	ambiguous, ok := err.(*AmbiguousError)
+
+	assert.
+		true(ok).
+		equals(2, len(ambiguous.Candidates)).
+		contains("20180101_users.sql, 20180102_user_roles.sql", err.Error())
+
+	_, err = selectNames("=movie")
+
+	assert.notNil(err)
+
+	selected, err = selectNames("=20180103_movies.sql")
+
+	assert.
+		nil(err).
+		applied(selected, "20180103_movies")
+}
+
+func TestSelectorNamesResolution(t *testing.T) {
+	all := []*Migration{
+		{Name: "20180101_users.sql"},
+		{Name: "20180102_user_roles.sql"},
+		{Name: "20180103_users_archive.sql"},
+		{Name: "20180104_roles.sql"},
+		{Name: "20180105_roles.sql"},
+	}
+
+	assert := assert(t)
+
+	selectNames := func(expr string, exact bool) ([]*Migration, error) {
+		selector, err := ParseSelector(expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if exact {
+			selector.RequireExactNames()
+		}
+
+		return selector.Select(all)
+	}
+
+	// users is contained in two names but is the exact name after the sequence of one
+	selected, err := selectNames("users", true)
+
+	assert.
+		nil(err).
+		applied(selected, "20180101_users")
+
+	selected, err = selectNames("archive", false)
+
+	assert.
+		nil(err).
+		applied(selected, "20180103_users_archive")
+
+	_, err = selectNames("archive", true)
+
+	assert.
+		notNil(err).
+		contains("Could not find the migration archive", err.Error())
+
+	_, err = selectNames("roles", false)
+
+	assert.
+		notNil(err).
+		contains("20180104_roles.sql, 20180105_roles.sql", err.Error())
+
+	selected, err = selectNames("20180105_roles", true)
+
+	assert.
+		nil(err).
+		applied(selected, "20180105_roles")
+}
+
+func TestMigrataurExactNames(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "20180101_users.sql"},
+		mockFileInfo{name: "20180102_user_roles.sql"},
+	)
+
+	assert := assert(t)
+	opts := DefaultOptions
+	opts.ExactNames = true
+	instance := New(newMockAdapter(), opts)
+
+	_, err := instance.Migrate("roles")
+
+	assert.notNil(err)
+
+	applied, err := instance.Migrate("user_roles")
+
+	assert.
+		nil(err).
+		applied(applied, "20180102_user_roles")
+}
+
+func TestMigrataurMigrateWithSelector(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql", content: `-- +migrataur tags: seed
+-- +migrataur up
+-- up
+-- -migrataur up
+
+-- +migrataur down
+-- down
+-- -migrataur down`},
+		mockFileInfo{name: "migration03.sql"},
+		mockFileInfo{name: "migration05.sql"},
+	)
+
+	assert := assert(t)
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	applied, err := instance.Migrate("migration01,migration05")
+
+	assert.
+		nil(err).
+		applied(applied, "migration01", "migration05")
+
+	applied, err = instance.Migrate("tag:seed")
+
+	assert.
+		nil(err).
+		applied(applied, "migration02").
+		equals("seed", applied[0].Tags()[0])
+
+	rollbacked, err := instance.Rollback("..migration02")
+
+	assert.
+		nil(err).
+		applied(rollbacked, "migration05", "migration02")
+}
@@ -0,0 +1,89 @@
+package migrataur
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetCurrentTimeFormattedMicro retrieves the current time formatted with a microsecond
+// resolution. Use it as the SequenceGenerator if migrations may be created within the
+// same second, by scripts for example.
+func GetCurrentTimeFormattedMicro() string {
+	return strings.Replace(time.Now().UTC().Format("20060102150405.000000"), ".", "", 1)
+}
+
+// IncrementalSequence builds a DirectorySequenceGenerator producing zero padded incremental
+// numbers, such as 0001, 0002 and so on. The next number is derived from files in the
+// migrations directory. It fails once numbers do not fit in the given width anymore since
+// migrations would not be sorted as expected, use Renumber with a larger width then.
+func IncrementalSequence(width int) func(directory string) (string, error) {
+	return func(directory string) (string, error) {
+		files, err := fsAdapter.ReadDir(directory)
+		last := 0
+
+		if err == nil {
+			for _, f := range files {
+				if f.IsDir() {
+					continue
+				}
+
+				if n, err := strconv.Atoi(migrationSequence(f.Name())); err == nil && n > last {
+					last = n
+				}
+			}
+		}
+
+		sequence := fmt.Sprintf("%0*d", width, last+1)
+
+		if len(sequence) > width {
+			return "", fmt.Errorf("the sequence %s does not fit in %d digits, renumber migrations with a larger width", sequence, width)
+		}
+
+		return sequence, nil
+	}
+}
+
+// migrationSequence retrieves the sequence prefix of a migration file name
+func migrationSequence(name string) string {
+	if idx := strings.Index(name, "_"); idx != -1 {
+		return name[:idx]
+	}
+
+	return ""
+}
+
+// writeNewMigration writes a newly created migration, refusing to overwrite an existing file.
+// Since migrations are sorted by name, it also refuses to reuse the sequence of another one.
+func (m *Migrataur) writeNewMigration(migration *Migration) error {
+	files, err := fsAdapter.ReadDir(m.options.Directory)
+
+	if err != nil {
+		if pathErr, ok := err.(*os.PathError); !ok || pathErr.Op != "open" {
+			return err
+		}
+	}
+
+	sequence := migrationSequence(migration.Name)
+
+	for _, f := range files {
+		if f.IsDir() || f.Name() == migration.Name {
+			continue
+		}
+
+		if sequence != "" && migrationSequence(f.Name()) == sequence {
+			return fmt.Errorf("\tThe sequence %s is already used by %s", sequence, f.Name())
+		}
+	}
+
+	// The file is created exclusively since another process may be creating the same one
+	err = migration.writeNewTo(m.getMigrationFullpath(migration.Name), m.options.MarshalOptions)
+
+	if os.IsExist(err) {
+		return fmt.Errorf("\tThe file %s already exists, it will not be overwritten", migration.Name)
+	}
+
+	return err
+}
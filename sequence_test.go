@@ -0,0 +1,92 @@
+package migrataur
+
+import "testing"
+
+func TestIncrementalSequence(t *testing.T) {
+	mockFSAdapter.empty()
+
+	assert := assert(t)
+	generator := IncrementalSequence(4)
+	sequence, err := generator("./migrations")
+
+	assert.
+		nil(err).
+		equals("0001", sequence)
+
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "0001_createMigrationHistory.sql"},
+		mockFileInfo{name: "0009_users.sql"},
+		mockFileInfo{name: "templates", dir: true},
+		mockFileInfo{name: "schema.sql"},
+	)
+
+	sequence, err = generator("./migrations")
+
+	assert.
+		nil(err).
+		equals("0010", sequence)
+
+	_, err = IncrementalSequence(1)("./migrations")
+
+	assert.notNil(err)
+
+	opts := DefaultOptions
+	opts.DirectorySequenceGenerator = generator
+	instance := New(newMockAdapter(), opts)
+
+	migration, err := instance.New("roles")
+
+	assert.
+		nil(err).
+		equals("0010_roles.sql", migration.Name)
+
+	// Overriding the directory is taken into account
+	mockFSAdapter.empty()
+	assert.nil(instance.OverrideDirectory("./other"))
+
+	migration, err = instance.New("roles")
+
+	assert.
+		nil(err).
+		equals("0001_roles.sql", migration.Name)
+}
+
+func TestGetCurrentTimeFormattedMicro(t *testing.T) {
+	assert := assert(t)
+	sequence := GetCurrentTimeFormattedMicro()
+
+	assert.
+		equals(20, len(sequence)).
+		equals(GetCurrentTimeFormatted()[:8], sequence[:8])
+}
+
+func TestMigrataurNewRefusesToOverwrite(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "0001_createMigrationHistory.sql"},
+		mockFileInfo{name: "0002_users.sql", content: "existing"},
+	)
+
+	assert := assert(t)
+	opts := DefaultOptions
+	opts.SequenceGenerator = func() string { return "0002" }
+	instance := New(newMockAdapter(), opts)
+
+	_, err := instance.New("users")
+
+	assert.
+		notNil(err).
+		contains("already exists", err.Error()).
+		equals("existing", mockFSAdapter.content("0002_users.sql"))
+
+	_, err = instance.New("movies")
+
+	assert.
+		notNil(err).
+		contains("already used by 0002_users.sql", err.Error()).
+		notExists("0002_movies.sql")
+
+	opts.SequenceGenerator = func() string { return "0001" }
+	_, err = New(newMockAdapter(), opts).Init()
+
+	assert.notNil(err)
+}
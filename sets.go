@@ -0,0 +1,123 @@
+package migrataur
+
+import (
+	"fmt"
+)
+
+// DefaultSetName is the name of the migrations set managed by the instance returned by New.
+const DefaultSetName = "default"
+
+// AddSet registers a new named set of migrations on this instance. A set has its own
+// directory and adapter (and so its own history), fields not given in opts are taken from
+// the parent instance, including its Logger and EventHandler if none is provided and its
+// MinLevel unless OwnMinLevel is set. dependsOn lists sets that must be migrated before
+// this one when calling MigrateAllSets.
+func (m *Migrataur) AddSet(name string, adapter Adapter, opts Options, dependsOn ...string) (*Migrataur, error) {
+	root := m.root
+
+	if name == "" {
+		return nil, fmt.Errorf("a set must have a name")
+	}
+
+	if _, err := root.Set(name); err == nil {
+		return nil, fmt.Errorf("the set %s already exists", name)
+	}
+
+	if opts.Directory == "" {
+		return nil, fmt.Errorf("the set %s must define its own directory", name)
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = root.options.Logger
+	}
+
+	if opts.EventHandler == nil {
+		opts.EventHandler = root.options.EventHandler
+	}
+
+	opts.ExactNames = opts.ExactNames || root.options.ExactNames
+	opts.Protected = opts.Protected || root.options.Protected
+
+	if !opts.OwnMinLevel {
+		opts.MinLevel = root.options.MinLevel
+	}
+
+	if opts.Orphans == OrphansFail {
+		opts.Orphans = root.options.Orphans
+	}
+
+	instance := &Migrataur{
+		adapter:   adapter,
+		options:   opts.ExtendWith(root.options),
+		set:       name,
+		root:      root,
+		dependsOn: dependsOn,
+	}
+
+	root.sets = append(root.sets, instance)
+
+	return instance, nil
+}
+
+// Set retrieves the instance managing the set with the given name. An empty name
+// returns the default set.
+func (m *Migrataur) Set(name string) (*Migrataur, error) {
+	if name == "" {
+		name = DefaultSetName
+	}
+
+	for _, set := range m.root.sets {
+		if set.set == name {
+			return set, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the set %s does not exist", name)
+}
+
+// SetName retrieves the name of the set managed by this instance.
+func (m *Migrataur) SetName() string {
+	return m.set
+}
+
+// Sets retrieves the names of all sets registered, in dependency order. It fails if a
+// set depends on an unknown one or if there is a cycle.
+func (m *Migrataur) Sets() ([]string, error) {
+	names := make([]string, len(m.root.sets))
+	dependencies := map[string][]string{}
+
+	for i, set := range m.root.sets {
+		names[i] = set.set
+		dependencies[set.set] = set.dependsOn
+	}
+
+	return sortByDependencies("set", names, dependencies)
+}
+
+// MigrateAllSets migrates every registered set to its latest version, in dependency order.
+// Given options apply to every set. It returns effectively applied migrations keyed by set name.
+func (m *Migrataur) MigrateAllSets(opts ...RunOption) (map[string][]*Migration, error) {
+	names, err := m.Sets()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string][]*Migration{}
+
+	for _, name := range names {
+		set, _ := m.Set(name)
+
+		m.step("Migrating set %s", name)
+
+		applied, err := set.MigrateToLatest(opts...)
+
+		if err != nil {
+			return result, err
+		}
+
+		result[name] = applied
+	}
+
+	return result, nil
+}
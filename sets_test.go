@@ -0,0 +1,150 @@
+package migrataur
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	assert := assert(t)
+
+	sorted, err := sortByDependencies("set", []string{"a", "b", "c", "d"}, map[string][]string{
+		"a": {"c"},
+		"b": {"d"},
+	})
+
+	assert.
+		nil(err).
+		equals(4, len(sorted)).
+		equals("c", sorted[0]).
+		equals("a", sorted[1]).
+		equals("d", sorted[2]).
+		equals("b", sorted[3])
+
+	_, err = sortByDependencies("set", []string{"a", "b"}, map[string][]string{
+		"a": {"unknown"},
+	})
+
+	assert.
+		notNil(err).
+		contains("unknown", err.Error())
+
+	_, err = sortByDependencies("set", []string{"a", "b", "c"}, map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	})
+
+	assert.
+		notNil(err).
+		contains("cycle", err.Error()).
+		contains("a, b", err.Error())
+}
+
+func TestMigrataurAddSet(t *testing.T) {
+	assert := assert(t)
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	plugins, err := instance.AddSet("plugins", newMockAdapter(), Options{
+		Directory:            "./plugins",
+		InitialMigrationName: "createPluginsHistory",
+	})
+
+	fullpath, _ := filepath.Abs("./plugins")
+
+	assert.
+		nil(err).
+		equals("plugins", plugins.SetName()).
+		equals(fullpath, plugins.options.Directory).
+		equals("createPluginsHistory", plugins.options.InitialMigrationName).
+		equals(instance.options.Extension, plugins.options.Extension)
+
+	_, err = instance.AddSet("plugins", newMockAdapter(), Options{Directory: "./other"})
+
+	assert.notNil(err)
+
+	_, err = instance.AddSet("nodirectory", newMockAdapter(), Options{})
+
+	assert.notNil(err)
+
+	opts := DefaultOptions
+	opts.MinLevel = LevelWarn
+	quiet := New(newMockAdapter(), opts)
+	inherited, _ := quiet.AddSet("inherited", newMockAdapter(), Options{Directory: "./inherited"})
+	own, _ := quiet.AddSet("own", newMockAdapter(), Options{Directory: "./own", OwnMinLevel: true})
+
+	assert.
+		equals(LevelWarn, inherited.options.MinLevel).
+		equals(LevelInfo, own.options.MinLevel)
+
+	set, err := plugins.Set("")
+
+	assert.
+		nil(err).
+		true(set == instance)
+
+	set, err = instance.Set("plugins")
+
+	assert.
+		nil(err).
+		true(set == plugins)
+
+	_, err = instance.Set("doesnotexists")
+
+	assert.notNil(err)
+}
+
+func TestMigrataurMigrateAllSets(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	instance.AddSet("billing", newMockAdapter(), Options{Directory: "./billing"}, "plugins")
+	instance.AddSet("plugins", newMockAdapter(), Options{Directory: "./plugins"}, DefaultSetName)
+
+	names, err := instance.Sets()
+
+	assert.
+		nil(err).
+		equals(3, len(names)).
+		equals(DefaultSetName, names[0]).
+		equals("plugins", names[1]).
+		equals("billing", names[2])
+
+	applied, err := instance.MigrateAllSets()
+
+	assert.
+		nil(err).
+		equals(3, len(applied))
+
+	for _, name := range names {
+		assert.applied(applied[name], "migration01", "migration02")
+	}
+
+	faked := New(newMockAdapter(), DefaultOptions)
+	faked.AddSet("plugins", newMockAdapter(), Options{Directory: "./plugins"})
+
+	applied, err = faked.MigrateAllSets(Fake(), WithRunID("deploy-42"))
+
+	assert.
+		nil(err).
+		equals(2, len(applied)).
+		applied(applied["plugins"], "migration01", "migration02")
+
+	for _, migrations := range applied {
+		for _, mig := range migrations {
+			assert.
+				true(mig.Fake).
+				equals("deploy-42", mig.RunID)
+		}
+	}
+
+	instance.AddSet("cyclic", newMockAdapter(), Options{Directory: "./cyclic"}, "cyclic")
+
+	_, err = instance.MigrateAllSets()
+
+	assert.notNil(err)
+}
@@ -0,0 +1,52 @@
+//go:build go1.21
+// +build go1.21
+
+package migrataur
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler sends events to the given slog.Logger. The migration name, direction,
+// duration, error and tenant are given as attributes when relevant.
+func SlogHandler(logger *slog.Logger) EventHandler {
+	return EventHandlerFunc(func(e Event) {
+		level := slog.LevelInfo
+
+		switch e.Level {
+		case LevelDebug:
+			level = slog.LevelDebug
+		case LevelWarn:
+			level = slog.LevelWarn
+		case LevelError:
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{}
+
+		if e.Migration != "" {
+			attrs = append(attrs,
+				slog.String("migration", e.Migration),
+				slog.String("direction", e.Direction.String()))
+		}
+
+		if e.Duration > 0 {
+			attrs = append(attrs, slog.Duration("duration", e.Duration))
+		}
+
+		if e.Fake {
+			attrs = append(attrs, slog.Bool("fake", true))
+		}
+
+		if e.Err != nil {
+			attrs = append(attrs, slog.String("error", e.Err.Error()))
+		}
+
+		if e.Tenant != "" {
+			attrs = append(attrs, slog.String("tenant", e.Tenant))
+		}
+
+		logger.LogAttrs(context.Background(), level, e.Message, attrs...)
+	})
+}
@@ -0,0 +1,187 @@
+package migrataur
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// squashedSuffix is appended to the name of the last migration of a range to build the name
+// of the squashed one. Doing so, it takes the place of the original migrations when sorting.
+const squashedSuffix = "_squashed"
+
+// Squash merges migrations of the given range into a single new one and removes the originals.
+// Up bodies are concatenated in order, down ones in reverse order unless one of the squashed
+// migrations is irreversible, making the new one irreversible too. If the squashed migrations
+// were applied, the adapter history is rewritten so the new migration is seen as applied. If
+// that fails, the squashed file is removed and the history put back as it was.
+// Other databases will have their history rewritten the next time migrations are applied or
+// by UpgradeHistory.
+func (m *Migrataur) Squash(rangeOrName string) (*Migration, error) {
+	m.step("Squashing %s", rangeOrName)
+
+	all, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.selectMigrations(all, rangeOrName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSquashable(all, migrations); err != nil {
+		return nil, m.fail(err)
+	}
+
+	squashed := squashMigrations(all, migrations)
+	path := m.getMigrationFullpath(squashed.Name)
+
+	if err = squashed.writeNewTo(path, m.options.MarshalOptions); err != nil {
+		if os.IsExist(err) {
+			return nil, m.fail(fmt.Errorf("\tThe file %s already exists, it will not be overwritten", squashed.Name))
+		}
+
+		return nil, m.fail(err)
+	}
+
+	m.migrationEvent(EventMigrationCreated, squashed, "migration created")
+
+	if migrations[0].HasBeenApplied() {
+		m.step("Rewriting history")
+
+		squashed.staleRows = migrations
+		resolveStaleRows(squashed)
+
+		if err = m.reconcileHistory([]*Migration{squashed}); err != nil {
+			// Left with the originals, the squashed migration would be seen as pending
+			m.revertSquashedHistory(squashed, migrations)
+
+			if removeErr := fsAdapter.Remove(path); removeErr != nil {
+				m.warn("Could not remove %s: %s", squashed.Name, removeErr)
+			}
+
+			return nil, m.fail(err)
+		}
+	}
+
+	m.step("Removing files")
+
+	for _, mig := range migrations {
+		if err = fsAdapter.Remove(m.getMigrationFullpath(mig.Name)); err != nil {
+			return nil, err
+		}
+
+		m.migrationEvent(EventMigrationDeleted, mig, "migration deleted")
+	}
+
+	return squashed, nil
+}
+
+// revertSquashedHistory records squashed migrations again and forgets the squashed one, so
+// that the history matches files left as they were
+func (m *Migrataur) revertSquashedHistory(squashed *Migration, migrations []*Migration) {
+	history, err := m.adapter.GetAll()
+
+	if err != nil {
+		m.warn("Could not revert the history: %s", err)
+		return
+	}
+
+	recorded := map[string]bool{}
+
+	for _, row := range history {
+		recorded[row.Name] = true
+	}
+
+	for _, mig := range migrations {
+		if recorded[mig.Name] {
+			continue
+		}
+
+		if err = m.adapter.MigrationApplied(mig); err != nil {
+			m.warn("Could not record %s again: %s", mig.Name, err)
+		}
+	}
+
+	if recorded[squashed.Name] {
+		if err = m.adapter.MigrationRollbacked(squashed); err != nil {
+			m.warn("Could not remove %s from the history: %s", squashed.Name, err)
+		}
+	}
+}
+
+// checkSquashable makes sure selected migrations could be merged together
+func checkSquashable(all, selected []*Migration) error {
+	if len(selected) < 2 {
+		return fmt.Errorf("at least two migrations are needed to squash them")
+	}
+
+	for _, mig := range selected {
+		if mig.IsInitial() {
+			return fmt.Errorf("the initial migration %s can not be squashed", mig.Name)
+		}
+
+		if mig.HasBeenApplied() != selected[0].HasBeenApplied() {
+			return fmt.Errorf("migrations to squash must either be all applied or all pending, %s is not", mig.Name)
+		}
+	}
+
+	return checkDependents(all, selected)
+}
+
+// squashMigrations builds the migration resulting from the merge of selected ones
+func squashMigrations(all, selected []*Migration) *Migration {
+	last := selected[len(selected)-1]
+	ext := filepath.Ext(last.Name)
+	index := indexMigrations(all)
+
+	squashed := &Migration{
+		Name:     strings.TrimSuffix(last.Name, ext) + squashedSuffix + ext,
+		replaces: make([]string, len(selected)),
+	}
+
+	var up, down bytes.Buffer
+	inSelection := map[*Migration]bool{}
+	dependencies := map[string]bool{}
+
+	for _, mig := range selected {
+		inSelection[mig] = true
+	}
+
+	for i, mig := range selected {
+		squashed.replaces[i] = mig.Name
+		// A partial down could not bring the database back, leave it empty
+		squashed.irreversible = squashed.irreversible || mig.IsIrreversible()
+
+		if i > 0 {
+			up.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&up, "-- %s\n%s", mig.Name, mig.up)
+
+		for _, depName := range mig.dependsOn {
+			if dep := index[depName]; !inSelection[dep] && !dependencies[dep.Name] {
+				dependencies[dep.Name] = true
+				squashed.dependsOn = append(squashed.dependsOn, dep.Name)
+			}
+		}
+	}
+
+	for i := len(selected) - 1; i >= 0 && !squashed.irreversible; i-- {
+		if i < len(selected)-1 {
+			down.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&down, "-- %s\n%s", selected[i].Name, selected[i].down)
+	}
+
+	squashed.up = up.String()
+	squashed.down = down.String()
+
+	return squashed
+}
@@ -0,0 +1,186 @@
+package migrataur
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMigrataurSquash(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql", content: `-- +migrataur up
+create a;
+-- -migrataur up
+-- +migrataur down
+drop a;
+-- -migrataur down`},
+		mockFileInfo{name: "migration03.sql", content: `-- +migrataur depends-on: migration01
+-- +migrataur up
+create b;
+-- -migrataur up
+-- +migrataur down
+drop b;
+-- -migrataur down`},
+		mockFileInfo{name: "migration04.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	// Keep a copy of the history as another database would have it
+	now := time.Now()
+	otherAdapter := newMockAdapter()
+	otherAdapter.appliedMigrations = []*Migration{
+		{Name: "migration01.sql", AppliedAt: &now},
+		{Name: "migration02.sql", AppliedAt: &now},
+		{Name: "migration03.sql", AppliedAt: &now},
+	}
+
+	_, err := instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	_, err = instance.Squash("migration02")
+
+	assert.notNil(err)
+
+	_, err = instance.Squash("migration01..migration02")
+
+	assert.notNil(err)
+
+	squashed, err := instance.Squash("migration02..migration03")
+
+	assert.
+		nil(err).
+		equals("migration03_squashed.sql", squashed.Name).
+		true(squashed.HasBeenApplied()).
+		equals("-- migration02.sql\ncreate a;\n\n-- migration03.sql\ncreate b;", squashed.up).
+		equals("-- migration03.sql\ndrop b;\n\n-- migration02.sql\ndrop a;", squashed.down).
+		equals(1, len(squashed.DependsOn())).
+		equals("migration01.sql", squashed.DependsOn()[0]).
+		exists("migration03_squashed.sql").
+		notExists("migration02.sql").
+		notExists("migration03.sql").
+		contains("-- +migrataur replaces: migration02.sql, migration03.sql", mockFSAdapter.content("migration03_squashed.sql")).
+		applied(adapter.appliedMigrations, "migration01", "migration04", "migration03_squashed")
+
+	other := New(otherAdapter, DefaultOptions)
+	migrations, err := other.GetAll()
+
+	// Reading migrations does not touch the history
+	assert.
+		nil(err).
+		applied(migrations, "migration01", "migration03_squashed", "migration04").
+		true(migrations[1].HasBeenApplied()).
+		false(migrations[2].HasBeenApplied()).
+		applied(otherAdapter.appliedMigrations, "migration01", "migration02", "migration03")
+
+	applied, err := other.MigrateToLatest()
+
+	assert.
+		nil(err).
+		applied(applied, "migration04").
+		applied(otherAdapter.appliedMigrations, "migration01", "migration03_squashed", "migration04")
+
+	// A database where only some of the squashed migrations were applied
+	partialAdapter := newMockAdapter()
+	partialAdapter.appliedMigrations = []*Migration{
+		{Name: "migration01.sql", AppliedAt: &now},
+		{Name: "migration02.sql", AppliedAt: &now},
+	}
+	partial := New(partialAdapter, DefaultOptions)
+
+	migrations, err = partial.GetAll()
+
+	assert.
+		nil(err).
+		true(migrations[1].IsPartiallyApplied()).
+		false(migrations[1].HasBeenApplied()).
+		contains("[~]", migrations[1].String()).
+		contains("1 of the 2", migrations[1].Details())
+
+	_, err = partial.MigrateToLatest()
+
+	assert.
+		notNil(err).
+		applied(partialAdapter.appliedMigrations, "migration01", "migration02")
+}
+
+func TestMigrataurSquashIrreversible(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql", content: DefaultMarshalOptions.Irreversible + "\n" + mockMigrationContent},
+		mockFileInfo{name: "migration03.sql"},
+		mockFileInfo{name: "migration04.sql"},
+	)
+
+	assert := assert(t)
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	squashed, err := instance.Squash("migration02..migration04")
+
+	assert.
+		nil(err).
+		true(squashed.IsIrreversible()).
+		equals("", squashed.down).
+		contains(DefaultMarshalOptions.Irreversible, mockFSAdapter.content("migration04_squashed.sql"))
+}
+
+// rollbackFailingAdapter fails to remove the second row removed from its history
+type rollbackFailingAdapter struct {
+	*mockAdapter
+	rollbacks int
+}
+
+func (a *rollbackFailingAdapter) MigrationRollbacked(migration *Migration) error {
+	a.rollbacks++
+
+	if a.rollbacks == 2 {
+		return fmt.Errorf("database is unreachable")
+	}
+
+	return a.mockAdapter.MigrationRollbacked(migration)
+}
+
+func TestMigrataurSquashFailures(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+	)
+
+	assert := assert(t)
+	adapter := newMockAdapter()
+	instance := New(&rollbackFailingAdapter{mockAdapter: adapter}, DefaultOptions)
+
+	_, err := instance.MigrateToLatest()
+
+	assert.nil(err)
+
+	_, err = instance.Squash("migration02..migration03")
+
+	assert.
+		notNil(err).
+		notExists("migration03_squashed.sql").
+		exists("migration02.sql").
+		exists("migration03.sql").
+		// migration02.sql had been removed before the failure and has been recorded again
+		applied(adapter.appliedMigrations, "migration01", "migration03", "migration02")
+
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+		mockFileInfo{name: "migration03.sql"},
+		mockFileInfo{name: "migration03_squashed.sql", content: "-- kept"},
+	)
+
+	_, err = New(newMockAdapter(), DefaultOptions).Squash("migration02..migration03")
+
+	assert.
+		notNil(err).
+		contains("already exists", err.Error()).
+		equals("-- kept", mockFSAdapter.content("migration03_squashed.sql")).
+		exists("migration02.sql")
+}
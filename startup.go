@@ -0,0 +1,205 @@
+package migrataur
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StartupMode tells what Startup should do with pending migrations.
+type StartupMode int
+
+const (
+	// StartupMigrate acquires the lock and applies pending migrations
+	StartupMigrate StartupMode = iota
+	// StartupCheck only checks that there is no pending migration and fails otherwise
+	StartupCheck
+	// StartupWait waits until pending migrations have been applied by another instance
+	StartupWait
+)
+
+// StartupOptions configures Startup.
+type StartupOptions struct {
+	Mode StartupMode
+	// PollInterval is the time waited between two attempts to acquire the lock or two
+	// checks of pending migrations, DefaultLockPollInterval if not set
+	PollInterval time.Duration
+	// Timeout, if greater than 0, is the maximum time waited for the lock or for another
+	// instance to apply pending migrations
+	Timeout time.Duration
+	// Readiness, if set, is marked ready once the database is up to date
+	Readiness *Readiness
+}
+
+// PendingError is returned by Startup when migrations are still pending.
+type PendingError struct {
+	Migrations []*Migration
+}
+
+func (e *PendingError) Error() string {
+	names := make([]string, len(e.Migrations))
+
+	for i, m := range e.Migrations {
+		names[i] = m.Name
+	}
+
+	return fmt.Sprintf("%d migration(s) pending: %s", len(names), strings.Join(names, ", "))
+}
+
+// Startup is meant to be called when an application starts to make sure its database is up
+// to date before serving anything. Depending on the mode, it applies pending migrations while
+// holding the adapter lock, only checks there is none, or waits for another instance to apply
+// them. In every case, the history is checked afterwards and a *PendingError is returned if
+// some migrations are still pending.
+func (m *Migrataur) Startup(opts StartupOptions) error {
+	err := m.startup(opts)
+
+	if opts.Readiness != nil {
+		opts.Readiness.set(err == nil, err)
+	}
+
+	return err
+}
+
+func (m *Migrataur) startup(opts StartupOptions) error {
+	switch opts.Mode {
+	case StartupMigrate:
+		pending, err := m.Pending()
+
+		if err != nil {
+			return m.fail(err)
+		}
+
+		if len(pending) > 0 {
+			release, err := m.acquireLock(opts.PollInterval, opts.Timeout)
+
+			if err != nil {
+				return err
+			}
+
+			defer release()
+
+			// Another instance may have applied them while we were waiting for the lock
+			if _, err = m.MigrateToLatest(); err != nil {
+				return err
+			}
+		}
+	case StartupWait:
+		return m.waitForPendingMigrations(opts.PollInterval, opts.Timeout)
+	}
+
+	pending, err := m.Pending()
+
+	if err != nil {
+		return m.fail(err)
+	}
+
+	if len(pending) > 0 {
+		return m.fail(&PendingError{Migrations: pending})
+	}
+
+	return nil
+}
+
+// waitForPendingMigrations checks pending migrations until there is none
+func (m *Migrataur) waitForPendingMigrations(pollInterval, timeout time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = DefaultLockPollInterval
+	}
+
+	startedAt := time.Now()
+	waiting := false
+
+	for {
+		pending, err := m.Pending()
+
+		if err != nil {
+			return m.fail(err)
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if timeout > 0 && time.Since(startedAt) >= timeout {
+			return m.fail(&PendingError{Migrations: pending})
+		}
+
+		if !waiting {
+			m.info("Waiting for another instance to apply %d migration(s)", len(pending))
+			waiting = true
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// Pending retrieves migrations not applied yet. Unlike GetAll, it does not emit any event,
+// so it can be called as often as needed.
+func (m *Migrataur) Pending() ([]*Migration, error) {
+	migrations, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []*Migration{}
+
+	for _, mig := range migrations {
+		if !mig.HasBeenApplied() {
+			pending = append(pending, mig)
+		}
+	}
+
+	return pending, nil
+}
+
+// Readiness tells if the database is ready to be used, as signaled by Startup. It can be
+// exposed as is on an HTTP health endpoint since it implements http.Handler.
+type Readiness struct {
+	mu    sync.RWMutex
+	ready bool
+	err   error
+}
+
+// Ready checks if Startup succeeded.
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ready
+}
+
+// Err retrieves the error returned by Startup, if any.
+func (r *Readiness) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.err
+}
+
+func (r *Readiness) set(ready bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ready, r.err = ready, err
+}
+
+// ServeHTTP responds with 200 when ready and 503 otherwise. The body never contains the
+// error returned by Startup since it may leak details about the database, use Err for that.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.Ready() {
+		fmt.Fprintln(w, "ready")
+		return
+	}
+
+	w.WriteHeader(http.StatusServiceUnavailable)
+
+	if r.Err() != nil {
+		fmt.Fprintln(w, "not ready")
+	} else {
+		fmt.Fprintln(w, "migrations in progress")
+	}
+}
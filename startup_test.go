@@ -0,0 +1,127 @@
+package migrataur
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// lockingAdapter implements Locker on top of the mock adapter
+type lockingAdapter struct {
+	*mockAdapter
+	held     bool
+	acquired int
+}
+
+func (a *lockingAdapter) TryLock() (bool, error) {
+	if a.held {
+		return false, nil
+	}
+
+	a.held = true
+	a.acquired++
+
+	return true, nil
+}
+
+func (a *lockingAdapter) Unlock() error {
+	a.held = false
+
+	return nil
+}
+
+func TestMigrataurStartupMigrate(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	adapter := &lockingAdapter{mockAdapter: newMockAdapter()}
+	readiness := &Readiness{}
+	instance := New(adapter, DefaultOptions)
+
+	assert.false(readiness.Ready())
+
+	err := instance.Startup(StartupOptions{Readiness: readiness})
+
+	assert.
+		nil(err).
+		true(readiness.Ready()).
+		equals(2, len(adapter.appliedMigrations)).
+		equals(1, adapter.acquired).
+		false(adapter.held)
+
+	// Nothing pending, the lock is not even needed
+	assert.
+		nil(instance.Startup(StartupOptions{})).
+		equals(1, adapter.acquired)
+
+	recorder := httptest.NewRecorder()
+	readiness.ServeHTTP(recorder, httptest.NewRequest("GET", "/ready", nil))
+
+	assert.equals(http.StatusOK, recorder.Code)
+}
+
+func TestMigrataurStartupLockTimeout(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	assert := assert(t)
+	adapter := &lockingAdapter{mockAdapter: newMockAdapter(), held: true}
+	instance := New(adapter, DefaultOptions)
+
+	err := instance.Startup(StartupOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      5 * time.Millisecond,
+	})
+
+	assert.
+		notNil(err).
+		contains("lock", err.Error()).
+		equals(0, len(adapter.appliedMigrations))
+}
+
+func TestMigrataurStartupCheckAndWait(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	assert := assert(t)
+	readiness := &Readiness{}
+	instance := New(newMockAdapter(), DefaultOptions)
+
+	err := instance.Startup(StartupOptions{Mode: StartupCheck, Readiness: readiness})
+	_, isPending := err.(*PendingError)
+
+	assert.
+		true(isPending).
+		contains("migration01.sql", err.Error()).
+		false(readiness.Ready()).
+		notNil(readiness.Err())
+
+	recorder := httptest.NewRecorder()
+	readiness.ServeHTTP(recorder, httptest.NewRequest("GET", "/ready", nil))
+
+	assert.
+		equals(http.StatusServiceUnavailable, recorder.Code).
+		equals("not ready\n", recorder.Body.String())
+
+	err = instance.Startup(StartupOptions{
+		Mode:         StartupWait,
+		PollInterval: time.Millisecond,
+		Timeout:      5 * time.Millisecond,
+	})
+	_, isPending = err.(*PendingError)
+
+	assert.true(isPending)
+
+	_, err = instance.MigrateToLatest()
+
+	assert.
+		nil(err).
+		nil(instance.Startup(StartupOptions{Mode: StartupWait, Readiness: readiness})).
+		true(readiness.Ready())
+}
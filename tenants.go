@@ -0,0 +1,206 @@
+package migrataur
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// TenantAdapterFactory constructs the adapter used to reach the database of the given tenant.
+type TenantAdapterFactory func(tenant string) (Adapter, error)
+
+// TenantRunnerOptions holds configuration specific to a TenantRunner.
+type TenantRunnerOptions struct {
+	// Concurrency is the maximum number of tenants migrated at the same time. Values
+	// lower than 1 means tenants are migrated one after the other.
+	Concurrency int
+	// ContinueOnError keeps migrating remaining tenants when one of them fails. When
+	// false, tenants not yet started are skipped after the first failure.
+	ContinueOnError bool
+}
+
+// TenantRunner applies the same set of migrations to many databases, one per tenant.
+type TenantRunner struct {
+	options       Options
+	runnerOptions TenantRunnerOptions
+	tenants       []string
+	factory       TenantAdapterFactory
+}
+
+// TenantResult holds the outcome of a run for a single tenant.
+type TenantResult struct {
+	Tenant  string
+	Applied []*Migration
+	Err     error
+	// Skipped is true when the tenant was not migrated at all because a previous one failed.
+	Skipped bool
+}
+
+// TenantReport collects results of a TenantRunner run, in the order tenants were given.
+type TenantReport struct {
+	Results []*TenantResult
+}
+
+// TenantError is returned by TenantReport.Err when at least one tenant failed.
+type TenantError struct {
+	Failed []*TenantResult
+}
+
+func (e *TenantError) Error() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "migrations failed for %d tenant(s)", len(e.Failed))
+
+	for _, r := range e.Failed {
+		fmt.Fprintf(&buf, "\n\t%s: %s", r.Tenant, r.Err)
+	}
+
+	return buf.String()
+}
+
+// NewTenantRunner instantiates a runner for the given tenants. The factory is called
+// once per tenant to retrieve its adapter, opts are shared by every tenant.
+func NewTenantRunner(tenants []string, factory TenantAdapterFactory, opts Options, runnerOpts TenantRunnerOptions) *TenantRunner {
+	return &TenantRunner{
+		options:       opts,
+		runnerOptions: runnerOpts,
+		tenants:       tenants,
+		factory:       factory,
+	}
+}
+
+// NewTenantRunnerWithAdapters instantiates a runner from already constructed adapters
+// keyed by tenant. Tenants will be processed in name order.
+func NewTenantRunnerWithAdapters(adapters map[string]Adapter, opts Options, runnerOpts TenantRunnerOptions) *TenantRunner {
+	tenants := make([]string, 0, len(adapters))
+
+	for tenant := range adapters {
+		tenants = append(tenants, tenant)
+	}
+
+	sort.Strings(tenants)
+
+	return NewTenantRunner(tenants, func(tenant string) (Adapter, error) {
+		return adapters[tenant], nil
+	}, opts, runnerOpts)
+}
+
+// MigrateToLatest migrates every tenant database to the latest version and returns
+// a report of what has been done. Use TenantReport.Err to know if something failed.
+func (r *TenantRunner) MigrateToLatest() *TenantReport {
+	return r.run(func(instance *Migrataur) ([]*Migration, error) {
+		return instance.MigrateToLatest()
+	})
+}
+
+// run calls fn for each tenant with a bounded concurrency
+func (r *TenantRunner) run(fn func(*Migrataur) ([]*Migration, error)) *TenantReport {
+	concurrency := r.runnerOptions.Concurrency
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	report := &TenantReport{Results: make([]*TenantResult, len(r.tenants))}
+	slots := make(chan struct{}, concurrency)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped bool
+	)
+
+	isStopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopped
+	}
+
+	for i, tenant := range r.tenants {
+		result := &TenantResult{Tenant: tenant}
+		report.Results[i] = result
+
+		slots <- struct{}{}
+
+		if isStopped() {
+			<-slots
+			result.Skipped = true
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(result *TenantResult) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			result.Applied, result.Err = r.runOne(result.Tenant, fn)
+
+			if result.Err != nil && !r.runnerOptions.ContinueOnError {
+				mu.Lock()
+				stopped = true
+				mu.Unlock()
+			}
+		}(result)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (r *TenantRunner) runOne(tenant string, fn func(*Migrataur) ([]*Migration, error)) ([]*Migration, error) {
+	adapter, err := r.factory(tenant)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := r.options
+	handler := opts.EventHandler
+
+	if handler == nil && opts.Logger != nil {
+		handler = PrintfHandler(opts.Logger)
+	}
+
+	if handler != nil {
+		opts.EventHandler = tenantHandler(handler, tenant)
+	}
+
+	return fn(New(adapter, opts))
+}
+
+// Failed retrieves results of tenants which have failed.
+func (r *TenantReport) Failed() []*TenantResult {
+	failed := []*TenantResult{}
+
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Err returns a *TenantError if at least one tenant failed, nil otherwise.
+func (r *TenantReport) Err() error {
+	failed := r.Failed()
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &TenantError{Failed: failed}
+}
+
+// tenantHandler sets the tenant of every event so concurrent runs stay readable
+func tenantHandler(handler EventHandler, tenant string) EventHandler {
+	return EventHandlerFunc(func(e Event) {
+		e.Tenant = tenant
+		handler.Handle(e)
+	})
+}
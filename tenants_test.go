@@ -0,0 +1,130 @@
+package migrataur
+
+import (
+	"fmt"
+	"testing"
+)
+
+// failingAdapter fails every Exec, used to simulate a broken tenant database
+type failingAdapter struct {
+	*mockAdapter
+}
+
+func (failingAdapter) Exec(command string) error {
+	return fmt.Errorf("database is unreachable")
+}
+
+func TestTenantRunnerMigrateToLatest(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+		mockFileInfo{name: "migration02.sql"},
+	)
+
+	assert := assert(t)
+	adapters := map[string]Adapter{
+		"tenant01": newMockAdapter(),
+		"tenant02": newMockAdapter(),
+		"tenant03": newMockAdapter(),
+	}
+
+	report := NewTenantRunnerWithAdapters(adapters, DefaultOptions, TenantRunnerOptions{Concurrency: 2}).MigrateToLatest()
+
+	assert.
+		nil(report.Err()).
+		equals(3, len(report.Results)).
+		equals(0, len(report.Failed()))
+
+	for i, result := range report.Results {
+		assert.
+			equals(fmt.Sprintf("tenant0%d", i+1), result.Tenant).
+			false(result.Skipped).
+			applied(result.Applied, "migration01", "migration02")
+	}
+}
+
+func TestTenantRunnerContinueOnError(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	assert := assert(t)
+	tenants := []string{"tenant01", "tenant02", "tenant03"}
+	factory := func(tenant string) (Adapter, error) {
+		switch tenant {
+		case "tenant01":
+			return failingAdapter{newMockAdapter()}, nil
+		case "tenant02":
+			return nil, fmt.Errorf("unknown tenant")
+		}
+
+		return newMockAdapter(), nil
+	}
+
+	report := NewTenantRunner(tenants, factory, DefaultOptions, TenantRunnerOptions{
+		Concurrency:     3,
+		ContinueOnError: true,
+	}).MigrateToLatest()
+
+	err := report.Err()
+
+	assert.
+		notNil(err).
+		equals(2, len(report.Failed())).
+		contains("tenant01", err.Error()).
+		contains("tenant02", err.Error()).
+		nil(report.Results[2].Err).
+		applied(report.Results[2].Applied, "migration01")
+}
+
+func TestTenantRunnerStopOnFirstError(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	assert := assert(t)
+	adapters := map[string]Adapter{
+		"tenant01": newMockAdapter(),
+		"tenant02": failingAdapter{newMockAdapter()},
+		"tenant03": newMockAdapter(),
+	}
+
+	report := NewTenantRunnerWithAdapters(adapters, DefaultOptions, TenantRunnerOptions{}).MigrateToLatest()
+
+	assert.
+		notNil(report.Err()).
+		equals(1, len(report.Failed())).
+		nil(report.Results[0].Err).
+		notNil(report.Results[1].Err).
+		true(report.Results[2].Skipped).
+		equals(0, len(report.Results[2].Applied))
+}
+
+func TestTenantRunnerEvents(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	assert := assert(t)
+	adapters := map[string]Adapter{
+		"tenant01": newMockAdapter(),
+		"tenant02": newMockAdapter(),
+	}
+	tenants := map[string]int{}
+
+	opts := DefaultOptions
+	opts.EventHandler = EventHandlerFunc(func(e Event) {
+		tenants[e.Tenant]++
+
+		if e.Kind == EventMigrationApplied {
+			assert.equals("["+e.Tenant+"] ✓\tmigration01.sql", FormatEvent(e))
+		}
+	})
+
+	report := NewTenantRunnerWithAdapters(adapters, opts, TenantRunnerOptions{}).MigrateToLatest()
+
+	assert.
+		nil(report.Err()).
+		equals(2, len(tenants)).
+		true(tenants["tenant01"] > 0).
+		true(tenants["tenant02"] > 0)
+}
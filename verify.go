@@ -0,0 +1,137 @@
+package migrataur
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errNotReversible is reported for migrations failing the roundtrip verification
+var errNotReversible = errors.New("not reversible")
+
+// RoundtripResult holds the outcome of the up, down and up again verification of a migration.
+type RoundtripResult struct {
+	Migration *Migration
+	// Err is set when one of the steps could not be executed
+	Err error
+	// SchemaChecked is true when schemas could be compared, that is when the adapter
+	// implements SchemaDumper
+	SchemaChecked bool
+	// Reversible is false when the schema after rolling back the migration differs from the
+	// one before applying it, or when applying it again does not give the same schema, in which
+	// case the verification fails. It is also false for migrations marked as irreversible but
+	// those are only applied and not reported as failures.
+	Reversible bool
+}
+
+// VerifyRoundtrip applies each pending migration, rolls it back and applies it again to make
+// sure down sections are working. If the adapter implements SchemaDumper, schemas are compared
+// between each step to detect non reversible migrations. Migrations marked as irreversible are
+// only applied and not reported as errors. Since it really executes migrations, it is intended
+// to be used against a throwaway database.
+func (m *Migrataur) VerifyRoundtrip() ([]*RoundtripResult, error) {
+	m.step("Verifying pending migrations")
+
+	migrations, err := m.getAllMigrations(Up)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dumper, _ := m.adapter.(SchemaDumper)
+	results := []*RoundtripResult{}
+	notReversible := []string{}
+
+	if dumper == nil {
+		m.warn("The adapter does not support schema dumps, only checking that commands succeed")
+	}
+
+	for _, mig := range migrations {
+		if mig.HasBeenApplied() {
+			continue
+		}
+
+		result := &RoundtripResult{
+			Migration:     mig,
+			SchemaChecked: dumper != nil,
+			Reversible:    true,
+		}
+
+		results = append(results, result)
+
+		if mig.IsIrreversible() {
+			m.emit(Event{
+				Kind:      EventWarning,
+				Message:   fmt.Sprintf("%s is irreversible, it will only be applied", mig.Name),
+				Migration: mig.Name,
+			})
+
+			result.Reversible = false
+
+			if _, result.Err = m.applyOne(mig, Up, runOptions{}); result.Err != nil {
+				return results, result.Err
+			}
+
+			continue
+		}
+
+		if result.Err = m.verifyOne(mig, dumper, result); result.Err != nil {
+			// Following migrations may need this one so there is no point going further
+			return results, result.Err
+		}
+
+		if !result.Reversible {
+			m.emit(Event{Kind: EventFailure, Message: "migration not reversible", Migration: mig.Name, Err: errNotReversible})
+			notReversible = append(notReversible, mig.Name)
+		}
+	}
+
+	if len(notReversible) > 0 {
+		return results, fmt.Errorf("some migrations are not reversible: %s", strings.Join(notReversible, ", "))
+	}
+
+	return results, nil
+}
+
+// verifyOne runs the up, down, up roundtrip for a single migration and updates the result
+func (m *Migrataur) verifyOne(migration *Migration, dumper SchemaDumper, result *RoundtripResult) error {
+	schemas := make([]string, 0, 4)
+	steps := []Direction{Up, Down, Up}
+
+	dump := func() error {
+		if dumper == nil {
+			return nil
+		}
+
+		schema, err := dumper.DumpSchema()
+
+		if err != nil {
+			return err
+		}
+
+		schemas = append(schemas, schema)
+
+		return nil
+	}
+
+	if err := dump(); err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		if _, err := m.applyOne(migration, step, runOptions{}); err != nil {
+			return err
+		}
+
+		if err := dump(); err != nil {
+			return err
+		}
+	}
+
+	if dumper != nil {
+		// Before up == after down and after up == after up again
+		result.Reversible = schemas[0] == schemas[2] && schemas[1] == schemas[3]
+	}
+
+	return nil
+}
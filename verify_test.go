@@ -0,0 +1,99 @@
+package migrataur
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// schemaAdapter simulates a database schema made of tables created by "create <name>"
+// and dropped by "drop <name>" commands
+type schemaAdapter struct {
+	*mockAdapter
+	tables map[string]bool
+}
+
+func newSchemaAdapter() *schemaAdapter {
+	return &schemaAdapter{mockAdapter: newMockAdapter(), tables: map[string]bool{}}
+}
+
+func (a *schemaAdapter) Exec(command string) error {
+	for _, line := range strings.Split(command, "\n") {
+		if strings.HasPrefix(line, "create ") {
+			a.tables[strings.TrimPrefix(line, "create ")] = true
+		} else if strings.HasPrefix(line, "drop ") {
+			delete(a.tables, strings.TrimPrefix(line, "drop "))
+		}
+	}
+
+	return a.mockAdapter.Exec(command)
+}
+
+func (a *schemaAdapter) DumpSchema() (string, error) {
+	tables := []string{}
+
+	for table := range a.tables {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	return strings.Join(tables, ","), nil
+}
+
+func TestMigrataurVerifyRoundtrip(t *testing.T) {
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql", content: `-- +migrataur up
+create horses
+-- -migrataur up
+-- +migrataur down
+drop horses
+-- -migrataur down`},
+		mockFileInfo{name: "migration02.sql", content: `-- +migrataur up
+create riders
+-- -migrataur up
+-- +migrataur down
+drop horses
+-- -migrataur down`},
+		mockFileInfo{name: "migration03.sql", content: `-- +migrataur up
+create stables
+-- -migrataur up
+-- +migrataur down
+drop stables
+-- -migrataur down`},
+	)
+
+	assert := assert(t)
+	adapter := newSchemaAdapter()
+	instance := New(adapter, DefaultOptions)
+
+	_, err := instance.Migrate("migration01")
+
+	assert.nil(err)
+
+	results, err := instance.VerifyRoundtrip()
+
+	assert.
+		notNil(err).
+		contains("migration02.sql", err.Error()).
+		equals(2, len(results)).
+		applied([]*Migration{results[0].Migration, results[1].Migration}, "migration02", "migration03").
+		true(results[0].SchemaChecked).
+		false(results[0].Reversible).
+		true(results[1].Reversible).
+		true(results[1].Migration.HasBeenApplied()).
+		equals(3, len(adapter.appliedMigrations))
+
+	// Without introspection, only commands are checked
+	mockFSAdapter.hasFiles(
+		mockFileInfo{name: "migration01.sql"},
+	)
+
+	results, err = New(struct{ Adapter }{newMockAdapter()}, DefaultOptions).VerifyRoundtrip()
+
+	assert.
+		nil(err).
+		equals(1, len(results)).
+		false(results[0].SchemaChecked).
+		true(results[0].Reversible)
+}
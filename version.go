@@ -0,0 +1,20 @@
+package migrataur
+
+// modulePath is used to find the migrataur version in the build information
+const modulePath = "github.com/YuukanOO/migrataur"
+
+// develVersion is used when the version is unknown
+const develVersion = "(devel)"
+
+// Version of migrataur, as found in the build information of the running binary with Go 1.12
+// or later. It is recorded in the history alongside applied migrations and is "(devel)" when
+// unknown. It can also be set at build time with:
+//
+//	-ldflags "-X github.com/YuukanOO/migrataur.Version=v1.2.3"
+var Version = develVersion
+
+func init() {
+	if Version == develVersion {
+		Version = moduleVersion()
+	}
+}
@@ -0,0 +1,26 @@
+//go:build go1.12
+// +build go1.12
+
+package migrataur
+
+import "runtime/debug"
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+
+	if !ok {
+		return develVersion
+	}
+
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+
+	return develVersion
+}
@@ -0,0 +1,9 @@
+//go:build !go1.12
+// +build !go1.12
+
+package migrataur
+
+// moduleVersion can not read the build information before Go 1.12
+func moduleVersion() string {
+	return develVersion
+}